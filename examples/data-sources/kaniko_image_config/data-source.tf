@@ -0,0 +1,4 @@
+data "kaniko_image_config" "example" {
+  reference = "docker.io/library/nginx:1.23"
+  platform  = "linux/amd64"
+}
@@ -0,0 +1,3 @@
+data "kaniko_image_digest" "example" {
+  reference = "docker.io/library/alpine:3.17"
+}
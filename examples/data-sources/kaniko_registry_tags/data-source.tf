@@ -0,0 +1,5 @@
+data "kaniko_registry_tags" "example" {
+  repository = "docker.io/seal-io/test"
+  filter     = "^v?1\\."
+  sort_by    = "semver"
+}
@@ -0,0 +1,21 @@
+resource "kaniko_cache_warmer" "example" {
+  claim_name = "kaniko-cache"
+  claim_size = "10Gi"
+  images = [
+    "docker.io/library/golang:1.19",
+    "docker.io/library/alpine:3.17",
+  ]
+}
+
+resource "kaniko_image" "example" {
+  context     = "git://github.com/seal-io/simple-web-service"
+  destination = "docker.io/seal-io/test:1"
+
+  cache {
+    enabled = true
+  }
+
+  cache_volume {
+    claim_name = kaniko_cache_warmer.example.claim_name
+  }
+}
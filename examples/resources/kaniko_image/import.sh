@@ -0,0 +1,5 @@
+# Images can be imported by their destination tag reference.
+terraform import kaniko_image.example docker.io/seal-io/test:1
+
+# Or by their digest reference, if a single tag of the repository points to it.
+terraform import kaniko_image.example docker.io/seal-io/test@sha256:0000000000000000000000000000000000000000000000000000000000000000
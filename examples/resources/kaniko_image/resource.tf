@@ -1,12 +1,16 @@
 resource "kaniko_image" "example" {
-  context     = ""
-  dockerfile  = ""
-  destination = ""
+  context     = "git://github.com/seal-io/simple-web-service#refs/heads/main"
+  dockerfile  = "Dockerfile"
+  destination = "docker.io/seal-io/simple-web-service:latest"
 
   build_arg = {
+    VERSION = "v0.0.1"
   }
 
-  cache        = false
   no_push      = false
   reproducible = false
-}
\ No newline at end of file
+
+  cache {
+    enabled = false
+  }
+}
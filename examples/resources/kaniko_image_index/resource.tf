@@ -0,0 +1,12 @@
+resource "kaniko_image_index" "example" {
+  destination = "docker.io/seal-io/test:1"
+
+  manifest {
+    reference = "docker.io/seal-io/test:1-amd64"
+    platform  = "linux/amd64"
+  }
+
+  manifest {
+    reference = "docker.io/seal-io/test:1-arm64"
+  }
+}
@@ -0,0 +1,7 @@
+resource "kaniko_image_tag" "example" {
+  source = "registry.example.com/staging/test@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+  destinations = [
+    "registry.example.com/prod/test:1",
+    "docker.io/seal-io/test:1",
+  ]
+}
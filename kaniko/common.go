@@ -2,27 +2,63 @@ package kaniko
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	apibatchv1 "k8s.io/api/batch/v1"
 	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	batchv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
 	"k8s.io/utils/pointer"
 )
 
 const (
 	defaultNamespace       = "default"
-	kanikoImage            = "gcr.io/kaniko-project/executor:v1.5.1"
+	kanikoImage            = "gcr.io/kaniko-project/executor:v1.23.2"
 	inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	buildContainerName     = "build"
+
+	dockerConfigKey         = "config.json"
+	registryCertificatesDir = "/kaniko/registry-certificates"
+
+	// How often and how long the pods of a job are checked for being unschedulable.
+	unschedulableCheckInterval = 30 * time.Second
+	unschedulableTimeout       = 10 * time.Minute
+)
+
+const (
+	managedBy = "terraform-provider-kaniko"
+
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	labelBuildID   = "kaniko.seal.io/build-id"
+	// The framework does not expose the address of a resource,
+	// so the destination is used to identify builds of the same image.
+	labelDestinationHash = "kaniko.seal.io/destination-hash"
+	labelInputHash       = "kaniko.seal.io/input-hash"
+)
+
+// Standard labels of the built images.
+const (
+	ociLabelCreated  = "org.opencontainers.image.created"
+	ociLabelSource   = "org.opencontainers.image.source"
+	ociLabelRevision = "org.opencontainers.image.revision"
 )
 
 type runOptions struct {
@@ -38,91 +74,569 @@ type runOptions struct {
 	RegistryUsername string
 	RegistryPassword string
 	Cache            bool
-	NoPush           bool
-	Reproducible     bool
+	CacheRepo        string
+	CacheTTL         string
+	CacheCopyLayers  bool
+	CacheRunLayers   *bool
+	CompressedCache  *bool
+	CacheClaimName   string
+	// Size, storage class and access mode to create the cache claim with if it does not exist.
+	CacheClaimSize         string
+	CacheClaimStorageClass string
+	CacheClaimAccessMode   string
+	NoPush                 bool
+	Reproducible           bool
+	Target                 string
+	SkipUnusedStages       bool
+	Platforms              []string
+	ImageLabels            map[string]string
+	// Whether to label the image with the standard labels of its source and creation time.
+	OCILabels bool
+	// Arbitrary values of the resource, they only change the input hash to rebuild the image.
+	Triggers         map[string]string
 	PushRetry        int64
 	Verbosity        string
+	KeepFailedBuilds bool
+
+	// Platform to build, one of Platforms, the image is pushed to a destination suffixed with it.
+	Platform string
+
+	// Registries to access over plain HTTP or without verifying TLS,
+	// and the CA certificates in PEM to verify the registries with by host.
+	InsecureRegistries   []string
+	SkipTLSVerify        bool
+	SkipTLSVerifyPull    bool
+	RegistryCertificates map[string]string
+
+	// Mirrors to pull the images of docker hub from, and the mirrors of other registries by registry.
+	RegistryMirrors []string
+	RegistryMap     map[string]string
+
+	Proxy proxyOptions
+
+	// Credentials of the cache repository, if it lives in another registry.
+	CacheRepoUsername string
+	CacheRepoPassword string
+	// Credentials of the other registries by host, e.g. to pull the base images.
+	RegistryAuths map[string]authn.AuthConfig
+
+	ServiceAccountName           string
+	AutomountServiceAccountToken *bool
+	PodAnnotations               map[string]string
+	PodLabels                    map[string]string
+	Annotations                  map[string]string
+	Labels                       map[string]string
+}
+
+// inputHash returns the hash of the options which affect the built image and of the triggers,
+// credentials and kubernetes settings are not part of it.
+func (o *runOptions) inputHash() string {
+	b, _ := json.Marshal([]any{
+		kanikoImage,
+		o.Context,
+		o.Dockerfile,
+		o.Destination,
+		o.BuildArg,
+		o.Cache,
+		o.NoPush,
+		o.Reproducible,
+		o.Target,
+		o.SkipUnusedStages,
+		o.Platforms,
+		o.ImageLabels,
+		o.OCILabels,
+		o.Triggers,
+	})
+	sum := sha256.Sum256(b)
+	// Label values are limited to 63 characters.
+	return hex.EncodeToString(sum[:])[:32]
+}
+
+// buildID returns the id of the build derived from its inputs,
+// so that the same inputs always run as the same job.
+func (o *runOptions) buildID() string {
+	if o.Platform != "" {
+		return "kaniko-" + o.inputHash()[:16] + "-" + platformSuffix(o.Platform)
+	}
+	return "kaniko-" + o.inputHash()[:16]
+}
+
+// pushDestination returns the destination the executor pushes to,
+// platform builds are pushed to a tag suffixed with the platform.
+func (o *runOptions) pushDestination() string {
+	if o.Platform == "" {
+		return o.Destination
+	}
+	ref, err := name.ParseReference(o.Destination)
+	if err != nil {
+		return o.Destination
+	}
+	tag := name.DefaultTag
+	if t, ok := ref.(name.Tag); ok {
+		tag = t.TagStr()
+	}
+	return ref.Context().Tag(tag + "-" + platformSuffix(o.Platform)).String()
+}
+
+// platformSuffix returns the platform in a form usable in names and tags, e.g. linux-arm64-v8.
+func platformSuffix(platform string) string {
+	return strings.ReplaceAll(platform, "/", "-")
+}
+
+// proxyOptions configures the proxies to access the registries and the build context through.
+type proxyOptions struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// isSet returns whether any proxy setting is set.
+func (o proxyOptions) isSet() bool {
+	return o.HTTPProxy != "" || o.HTTPSProxy != "" || o.NoProxy != ""
+}
+
+// env returns the environment variables of the proxy settings,
+// in both cases as tools differ in which one they read.
+func (o proxyOptions) env() []apiv1.EnvVar {
+	var env []apiv1.EnvVar
+	for _, e := range []struct{ name, value string }{
+		{"HTTP_PROXY", o.HTTPProxy},
+		{"HTTPS_PROXY", o.HTTPSProxy},
+		{"NO_PROXY", o.NoProxy},
+	} {
+		if e.value == "" {
+			continue
+		}
+		env = append(env,
+			apiv1.EnvVar{Name: e.name, Value: e.value},
+			apiv1.EnvVar{Name: strings.ToLower(e.name), Value: e.value})
+	}
+	return env
+}
+
+// runResult describes the job which ran a build.
+type runResult struct {
+	ID     string
+	Digest string
 }
 
 type DockerConfigJSON struct {
 	Auths map[string]authn.AuthConfig
 }
 
-func kanikoBuild(ctx context.Context, restConfig *rest.Config, opts *runOptions) error {
+func kanikoBuild(ctx context.Context, restConfig *rest.Config, opts *runOptions) (*runResult, error) {
 	coreV1Client, err := v1.NewForConfig(restConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	batchV1Client, err := batchv1.NewForConfig(restConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	namespace := defaultNamespace
-	if _, err = os.Stat(inClusterNamespaceFile); err == nil {
-		namespaceBytes, err := os.ReadFile(inClusterNamespaceFile)
-		if err == nil {
-			namespace = string(namespaceBytes)
+	namespace := getNamespace()
+
+	// Adopt the job left behind by an interrupted run with the same inputs,
+	// instead of launching a duplicate. The input hash label is how the job is found again,
+	// as the private state of the resource cannot be persisted before the build returns.
+	adopted, err := findAdoptableJob(ctx, batchV1Client, namespace, opts)
+	if err != nil {
+		return nil, err
+	}
+	if adopted != nil {
+		tflog.Info(ctx, "adopting existing kaniko job", map[string]any{"namespace": namespace, "name": adopted.Name})
+		opts.ID = adopted.Name
+	} else {
+		if opts.CacheClaimSize != "" {
+			err = ensureCacheClaim(ctx, coreV1Client, namespace, cacheClaimOptions{
+				Name:         opts.CacheClaimName,
+				Size:         opts.CacheClaimSize,
+				StorageClass: opts.CacheClaimStorageClass,
+				AccessMode:   opts.CacheClaimAccessMode,
+				Annotations:  getAnnotations(opts),
+				Labels:       opts.Labels,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		secret, err := getDockerConfigSecret(namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		job := getKanikoJob(namespace, opts)
+		if err = submitJob(ctx, coreV1Client, batchV1Client, secret, job); err != nil {
+			return nil, err
 		}
 	}
 
-	ref, err := name.ParseReference(opts.Destination)
+	var failed bool
+	defer func() {
+		cleanupJob(ctx, coreV1Client, batchV1Client, namespace, opts.ID, failed && opts.KeepFailedBuilds)
+	}()
+
+	succeeded, err := waitForJob(ctx, coreV1Client, batchV1Client, namespace, opts.ID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !succeeded {
+		failed = true
+		return nil, getJobFailure(ctx, restConfig, namespace, opts.ID, opts.KeepFailedBuilds)
+	}
+
+	digest, err := getJobDigest(ctx, namespace, opts.ID, restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("kaniko job succeeded, but cannot get image digest: %w", err)
 	}
-	registry := fmt.Sprintf("https://%s/v1/", ref.Context().RegistryStr())
-	secret, err := getDockerConfigSecret(namespace, opts.ID, registry, opts.RegistryUsername, opts.RegistryPassword)
+
+	return &runResult{ID: opts.ID, Digest: digest}, nil
+}
+
+// cacheClaimOptions configures the persistent volume claim of the cache to create.
+type cacheClaimOptions struct {
+	Name         string
+	Size         string
+	StorageClass string
+	AccessMode   string
+	Annotations  map[string]string
+	Labels       map[string]string
+}
+
+// ensureCacheClaim creates the persistent volume claim of the cache if it does not exist,
+// it is shared by the builds and the warmer, and never deleted by the provider.
+func ensureCacheClaim(ctx context.Context, client v1.CoreV1Interface, namespace string, opts cacheClaimOptions) error {
+	size, err := resource.ParseQuantity(opts.Size)
 	if err != nil {
 		return err
 	}
 
-	if _, err := coreV1Client.Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+	labels := make(map[string]string)
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	labels[labelManagedBy] = managedBy
+
+	// The claim is shared by the builds and the warmer which may run on different nodes.
+	accessMode := apiv1.ReadWriteMany
+	if opts.AccessMode != "" {
+		accessMode = apiv1.PersistentVolumeAccessMode(opts.AccessMode)
+	}
+
+	pvc := &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        opts.Name,
+			Labels:      labels,
+			Annotations: opts.Annotations,
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			AccessModes: []apiv1.PersistentVolumeAccessMode{accessMode},
+			Resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{
+					apiv1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if opts.StorageClass != "" {
+		pvc.Spec.StorageClassName = pointer.String(opts.StorageClass)
+	}
+
+	_, err = client.PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
 		return err
 	}
+	return nil
+}
 
-	job := getKanikoJob(namespace, opts)
-	if _, err := batchV1Client.Jobs(namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+// submitJob creates the given job and its secret if any.
+func submitJob(
+	ctx context.Context,
+	coreV1Client v1.CoreV1Interface,
+	batchV1Client batchv1.BatchV1Interface,
+	secret *apiv1.Secret,
+	job *apibatchv1.Job,
+) error {
+	if secret != nil {
+		// The secret may be left behind by an interrupted run with the same inputs.
+		_, err := coreV1Client.Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+		if kerrors.IsAlreadyExists(err) {
+			_, err = coreV1Client.Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// A failed job kept for debugging holds the name of the job.
+	propagation := metav1.DeletePropagationBackground
+	deleteOpts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+	err := batchV1Client.Jobs(job.Namespace).Delete(ctx, job.Name, deleteOpts)
+	if err != nil && !kerrors.IsNotFound(err) {
 		return err
 	}
 
-	defer func() {
-		// Clean up.
-		if err = batchV1Client.Jobs(namespace).Delete(ctx, opts.ID, metav1.DeleteOptions{}); err != nil {
-			tflog.Warn(ctx, "failed to clean up kaniko job", map[string]any{"error": err})
+	// A concurrent run with the same inputs may have created the job meanwhile,
+	// in which case it is watched instead.
+	_, err = batchV1Client.Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// waitForJob watches the given job until it finishes, and returns whether it succeeded,
+// it fails if a pod of the job cannot be scheduled, e.g. as no node is of the platform to build.
+// The watch is resumed from the last seen version when closed by the API server during long builds.
+func waitForJob(
+	ctx context.Context,
+	coreV1Client v1.CoreV1Interface,
+	batchV1Client batchv1.BatchV1Interface,
+	namespace, name string,
+) (bool, error) {
+	jobs := batchV1Client.Jobs(namespace)
+	lw := &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = "metadata.name=" + name
+			return jobs.Watch(ctx, options)
+		},
+	}
+
+	// The job is not updated while its pods are pending.
+	ticker := time.NewTicker(unschedulableCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		// Watch from the current version of the job, it is got again if the version expired.
+		job, err := jobs.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
 		}
-		if err = coreV1Client.Secrets(namespace).Delete(ctx, opts.ID, metav1.DeleteOptions{}); err != nil {
-			tflog.Warn(ctx, "failed to clean up kaniko secret", map[string]any{"error": err})
+		if finished, succeeded := isJobFinished(job); finished {
+			return succeeded, nil
 		}
-	}()
 
-	pw, err := batchV1Client.Jobs(namespace).Watch(ctx, metav1.ListOptions{})
+		pw, err := watchtools.NewRetryWatcher(job.ResourceVersion, lw)
+		if err != nil {
+			return false, err
+		}
+		finished, succeeded, err := watchJob(ctx, pw, ticker.C, func() error {
+			return checkJobSchedulable(ctx, coreV1Client, batchV1Client, namespace, name)
+		})
+		pw.Stop()
+		if err != nil || finished {
+			return succeeded, err
+		}
+		tflog.Debug(ctx, "watching kaniko job again", map[string]any{"namespace": namespace, "name": name})
+	}
+}
+
+// watchJob receives the events of the given job watch until the job finishes,
+// it returns false if the watch is closed before, e.g. as the version to watch from expired.
+func watchJob(
+	ctx context.Context,
+	pw watch.Interface,
+	tick <-chan time.Time,
+	checkSchedulable func() error,
+) (finished, succeeded bool, err error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		case <-tick:
+			if err = checkSchedulable(); err != nil {
+				return false, false, err
+			}
+		case e, ok := <-pw.ResultChan():
+			if !ok {
+				return false, false, nil
+			}
+			if e.Type == watch.Error {
+				if err = kerrors.FromObject(e.Object); kerrors.IsGone(err) || kerrors.IsResourceExpired(err) {
+					return false, false, nil
+				}
+				return false, false, fmt.Errorf("failed to watch kaniko job: %w", err)
+			}
+			p, ok := e.Object.(*apibatchv1.Job)
+			if !ok {
+				tflog.Warn(ctx, "unexpected k8s resource event", map[string]any{"event": e})
+				continue
+			}
+			if finished, succeeded = isJobFinished(p); finished {
+				return true, succeeded, nil
+			}
+		}
+	}
+}
+
+// isJobFinished returns whether the given job finished, and whether it succeeded.
+func isJobFinished(job *apibatchv1.Job) (finished, succeeded bool) {
+	switch {
+	case job.Status.CompletionTime != nil:
+		return true, true
+	case job.Status.Failed > 0:
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// checkJobSchedulable returns an error if a pod of the given job has been unschedulable for too long,
+// the pods are given time to be scheduled on the nodes added by the cluster autoscaler.
+func checkJobSchedulable(
+	ctx context.Context,
+	coreV1Client v1.CoreV1Interface,
+	batchV1Client batchv1.BatchV1Interface,
+	namespace, name string,
+) error {
+	pods, err := listJobPods(ctx, coreV1Client, batchV1Client, namespace, name)
 	if err != nil {
-		return err
+		tflog.Warn(ctx, "failed to check the kaniko job pods", map[string]any{"error": err})
+		return nil
 	}
 
-	for e := range pw.ResultChan() {
-		p, ok := e.Object.(*apibatchv1.Job)
-		if !ok {
-			tflog.Warn(ctx, "unexpected k8s resource event", map[string]any{"event": e})
-			continue
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.Conditions {
+			if c.Type != apiv1.PodScheduled || c.Status != apiv1.ConditionFalse ||
+				c.Reason != apiv1.PodReasonUnschedulable {
+				continue
+			}
+			if time.Since(c.LastTransitionTime.Time) > unschedulableTimeout {
+				return fmt.Errorf("pod %s of job %s/%s cannot be scheduled for %s: %s",
+					pod.Name, namespace, name, unschedulableTimeout, c.Message)
+			}
 		}
-		if p.Name != opts.ID {
+	}
+	return nil
+}
+
+// cleanupJob deletes the given job and its secret,
+// the job is kept if required, it is removed by kubernetes once its TTL expires.
+func cleanupJob(
+	ctx context.Context,
+	coreV1Client v1.CoreV1Interface,
+	batchV1Client batchv1.BatchV1Interface,
+	namespace, name string,
+	keepJob bool,
+) {
+	propagation := metav1.DeletePropagationBackground
+	deleteOpts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+	if !keepJob {
+		if err := batchV1Client.Jobs(namespace).Delete(ctx, name, deleteOpts); err != nil {
+			tflog.Warn(ctx, "failed to clean up kaniko job", map[string]any{"error": err})
+		}
+	}
+	err := coreV1Client.Secrets(namespace).Delete(ctx, name, deleteOpts)
+	if err != nil && !kerrors.IsNotFound(err) {
+		tflog.Warn(ctx, "failed to clean up kaniko secret", map[string]any{"error": err})
+	}
+}
+
+// getJobFailure returns the error of a failed job with the logs of its pods.
+func getJobFailure(ctx context.Context, restConfig *rest.Config, namespace, name string, kept bool) error {
+	var hint string
+	if kept {
+		hint = getKeptJobHint(namespace, name)
+	}
+	logs, err := getJobPodsLogs(ctx, namespace, name, restConfig)
+	if err != nil {
+		return fmt.Errorf("kaniko job failed, but cannot get pod logs: %w%s", err, hint)
+	}
+	return fmt.Errorf("build logs: %s%s", logs, hint)
+}
+
+// findAdoptableJob returns the job started by an earlier run with the same inputs,
+// which is still running or has succeeded.
+func findAdoptableJob(
+	ctx context.Context,
+	client batchv1.BatchV1Interface,
+	namespace string,
+	opts *runOptions,
+) (*apibatchv1.Job, error) {
+	ls := fmt.Sprintf("%s=%s,%s=%s", labelManagedBy, managedBy, labelInputHash, opts.inputHash())
+	jobs, err := client.Jobs(namespace).List(ctx, metav1.ListOptions{LabelSelector: ls})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		// Platform builds of the same image share the input hash.
+		if job.Name != opts.ID || job.DeletionTimestamp != nil || job.Status.Failed > 0 {
 			continue
 		}
-		if p.Status.CompletionTime != nil {
-			// Succeeded.
-			break
+		return job, nil
+	}
+
+	return nil, nil
+}
+
+// getNamespace returns the namespace to run the builds in,
+// which is the namespace of the provider if it runs inside a cluster.
+func getNamespace() string {
+	namespace := defaultNamespace
+	if _, err := os.Stat(inClusterNamespaceFile); err == nil {
+		namespaceBytes, err := os.ReadFile(inClusterNamespaceFile)
+		if err == nil {
+			namespace = string(namespaceBytes)
+		}
+	}
+	return namespace
+}
+
+// getKeptJobHint returns the kubectl commands to inspect a kept failed job.
+func getKeptJobHint(namespace, jobName string) string {
+	return fmt.Sprintf("\n\nThe failed job is kept until its TTL expires, inspect it with:\n"+
+		"  kubectl -n %[1]s describe job/%[2]s\n"+
+		"  kubectl -n %[1]s get pods -l job-name=%[2]s\n"+
+		"  kubectl -n %[1]s logs job/%[2]s\n", namespace, jobName)
+}
+
+// listJobPods returns the pods of the given job,
+// they are selected by the uid of the job as the pods of a previous job with the same name may be left.
+func listJobPods(
+	ctx context.Context,
+	coreV1Client v1.CoreV1Interface,
+	batchV1Client batchv1.BatchV1Interface,
+	namespace, name string,
+) (*apiv1.PodList, error) {
+	job, err := batchV1Client.Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return coreV1Client.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+}
+
+// getJobDigest returns the image digest reported by the succeeded pod of a job,
+// kaniko writes it to the termination message of the build container.
+func getJobDigest(ctx context.Context, namespace, jobName string, restConfig *rest.Config) (string, error) {
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", err
+	}
+	pods, err := listJobPods(ctx, clientSet.CoreV1(), clientSet.BatchV1(), namespace, jobName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != apiv1.PodSucceeded {
+			continue
 		}
-		if p.Status.Failed > 0 {
-			logs, err := getJobPodsLogs(ctx, namespace, opts.ID, restConfig)
-			if err != nil {
-				return fmt.Errorf("kaniko job failed, but cannot get pod logs: %w", err)
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != buildContainerName || cs.State.Terminated == nil {
+				continue
 			}
-			return fmt.Errorf("build logs: %s", logs)
+			return strings.TrimSpace(cs.State.Terminated.Message), nil
 		}
 	}
 
-	return nil
+	return "", nil
 }
 
 // getJobPodsLogs returns the logs of all pods of a job.
@@ -131,9 +645,7 @@ func getJobPodsLogs(ctx context.Context, namespace, jobName string, restConfig *
 	if err != nil {
 		return "", err
 	}
-	ls := "job-name=" + jobName
-	pods, err := clientSet.CoreV1().Pods(namespace).
-		List(ctx, metav1.ListOptions{LabelSelector: ls})
+	pods, err := listJobPods(ctx, clientSet.CoreV1(), clientSet.BatchV1(), namespace, jobName)
 	if err != nil {
 		return "", err
 	}
@@ -151,43 +663,215 @@ func getJobPodsLogs(ctx context.Context, namespace, jobName string, restConfig *
 	return logs, nil
 }
 
-func getDockerConfigSecret(namespace, name, registry, username, password string) (*apiv1.Secret, error) {
-	cfg := DockerConfigJSON{
-		Auths: map[string]authn.AuthConfig{
-			registry: {
-				Username: username,
-				Password: password,
-			},
-		},
+// getLabels returns the labels of the kubernetes objects created for a build,
+// the standard labels take precedence over the user-supplied ones.
+func getLabels(opts *runOptions, extra ...map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for _, m := range append([]map[string]string{opts.Labels}, extra...) {
+		for k, v := range m {
+			labels[k] = v
+		}
 	}
-	data, err := json.Marshal(cfg)
+
+	destinationHash := sha256.Sum256([]byte(opts.Destination))
+	labels[labelManagedBy] = managedBy
+	labels[labelBuildID] = opts.ID
+	labels[labelDestinationHash] = hex.EncodeToString(destinationHash[:])[:16]
+	labels[labelInputHash] = opts.inputHash()
+	return labels
+}
+
+// getAnnotations returns the annotations of the kubernetes objects created for a build.
+func getAnnotations(opts *runOptions, extra ...map[string]string) map[string]string {
+	annotations := make(map[string]string)
+	for _, m := range append([]map[string]string{opts.Annotations}, extra...) {
+		for k, v := range m {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
+
+// hasCredentials returns whether credentials of a registry are set.
+func (o *runOptions) hasCredentials() bool {
+	return len(o.RegistryAuths) != 0 ||
+		o.RegistryUsername != "" && o.RegistryPassword != "" ||
+		o.CacheRepo != "" && o.CacheRepoUsername != "" && o.CacheRepoPassword != ""
+}
+
+func getDockerConfigSecret(namespace string, opts *runOptions) (*apiv1.Secret, error) {
+	cfg := DockerConfigJSON{Auths: make(map[string]authn.AuthConfig)}
+	for host, auth := range opts.RegistryAuths {
+		cfg.Auths[registryAuthKey(host)] = auth
+	}
+
+	var registry string
+	if opts.RegistryUsername != "" && opts.RegistryPassword != "" {
+		ref, err := name.ParseReference(opts.Destination)
+		if err != nil {
+			return nil, err
+		}
+		registry = registryAuthKey(ref.Context().RegistryStr())
+		cfg.Auths[registry] = authn.AuthConfig{
+			Username: opts.RegistryUsername,
+			Password: opts.RegistryPassword,
+		}
+	}
+
+	// The cache repository may live in another registry.
+	if opts.CacheRepo != "" && opts.CacheRepoUsername != "" && opts.CacheRepoPassword != "" {
+		repo, err := name.NewRepository(opts.CacheRepo)
+		if err != nil {
+			return nil, err
+		}
+		if cacheRegistry := registryAuthKey(repo.RegistryStr()); cacheRegistry != registry {
+			cfg.Auths[cacheRegistry] = authn.AuthConfig{
+				Username: opts.CacheRepoUsername,
+				Password: opts.CacheRepoPassword,
+			}
+		}
+	}
+
+	data, err := getRegistrySecretData(&cfg, opts.RegistryCertificates)
 	if err != nil {
 		return nil, err
 	}
 
 	return &apiv1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      name,
-		},
-		Data: map[string][]byte{
-			"config.json": data,
+			Namespace:   namespace,
+			Name:        opts.ID,
+			Labels:      getLabels(opts),
+			Annotations: getAnnotations(opts),
 		},
+		Data: data,
 	}, nil
 }
 
-func getKanikoJob(namespace string, opts *runOptions) *apibatchv1.Job {
-	args := []string{
-		fmt.Sprintf("--dockerfile=%s", opts.Dockerfile),
-		fmt.Sprintf("--context=%s", opts.Context),
-		fmt.Sprintf("--destination=%s", opts.Destination),
-		fmt.Sprintf("--push-retry=%d", opts.PushRetry),
-		fmt.Sprintf("--verbosity=%s", opts.Verbosity),
+// getImageLabels returns the labels of the image to build,
+// the standard labels are added if enabled and not set.
+func getImageLabels(opts *runOptions, created time.Time) map[string]string {
+	labels := make(map[string]string, len(opts.ImageLabels)+3)
+	if opts.OCILabels {
+		// The creation time would make the image differ between builds.
+		if !opts.Reproducible {
+			labels[ociLabelCreated] = created.UTC().Format(time.RFC3339)
+		}
+		if source, revision, ok := parseGitContext(opts.Context); ok {
+			labels[ociLabelSource] = source
+			if revision != "" {
+				labels[ociLabelRevision] = revision
+			}
+		}
+	}
+	for k, v := range opts.ImageLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// parseGitContext returns the repository URL and the revision of a git build context,
+// e.g. git://github.com/org/repo.git#refs/heads/main#commit, the revision is the commit if set or the ref.
+func parseGitContext(buildContext string) (string, string, bool) {
+	const scheme = "git://"
+	if !strings.HasPrefix(buildContext, scheme) {
+		return "", "", false
 	}
 
+	parts := strings.Split(strings.TrimPrefix(buildContext, scheme), "#")
+	// Leave out the credentials if any.
+	repo := parts[0]
+	if i := strings.Index(repo, "@"); i >= 0 && i < strings.Index(repo+"/", "/") {
+		repo = repo[i+1:]
+	}
+	source := "https://" + repo
+	var revision string
+	for _, p := range parts[1:] {
+		if p != "" {
+			revision = p
+		}
+	}
+	return source, revision, true
+}
+
+// getRegistrySecretData returns the secret data of the docker config if any and of the registry certificates.
+func getRegistrySecretData(cfg *DockerConfigJSON, certificates map[string]string) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	if cfg != nil {
+		b, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		data[dockerConfigKey] = b
+	}
+	for i, host := range getRegistryCertificateHosts(certificates) {
+		data[getRegistryCertificateKey(i)] = []byte(certificates[host])
+	}
+	return data, nil
+}
+
+// registryAuthKey returns the key of the credentials of the given registry host in the docker config.
+func registryAuthKey(host string) string {
+	return fmt.Sprintf("https://%s/v1/", host)
+}
+
+// getRegistryCertificateHosts returns the sorted hosts of the registry certificates.
+func getRegistryCertificateHosts(certificates map[string]string) []string {
+	hosts := make([]string, 0, len(certificates))
+	for host := range certificates {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// getRegistryCertificateKey returns the secret key of the i-th registry certificate,
+// the host is not used as secret keys cannot contain the port separator.
+func getRegistryCertificateKey(i int) string {
+	return fmt.Sprintf("registry-certificate-%d.crt", i)
+}
+
+// getRegistryCertificateArgs returns the executor args of the registry certificates,
+// and the secret items to mount them from.
+func getRegistryCertificateArgs(certificates map[string]string) ([]string, []apiv1.KeyToPath) {
+	hosts := getRegistryCertificateHosts(certificates)
+	args := make([]string, 0, len(hosts))
+	items := make([]apiv1.KeyToPath, 0, len(hosts))
+	for i, host := range hosts {
+		key := getRegistryCertificateKey(i)
+		args = append(args, fmt.Sprintf("--registry-certificate=%s=%s/%s", host, registryCertificatesDir, key))
+		items = append(items, apiv1.KeyToPath{Key: key, Path: key})
+	}
+	return args, items
+}
+
+// getRegistryMirrorArgs returns the executor args of the registry mirrors.
+func getRegistryMirrorArgs(mirrors []string, registryMap map[string]string) []string {
+	args := make([]string, 0, len(mirrors)+len(registryMap))
+	for _, m := range mirrors {
+		args = append(args, fmt.Sprintf("--registry-mirror=%s", m))
+	}
+	registryMapKeys := make([]string, 0, len(registryMap))
+	for k := range registryMap {
+		registryMapKeys = append(registryMapKeys, k)
+	}
+	sort.Strings(registryMapKeys)
+	for _, k := range registryMapKeys {
+		args = append(args, fmt.Sprintf("--registry-map=%s=%s", k, registryMap[k]))
+	}
+	return args
+}
+
+// getRegistrySecretVolumes returns the volumes to mount the docker config and the registry certificates
+// from the secret of the given name.
+func getRegistrySecretVolumes(
+	secretName string,
+	dockerConfig bool,
+	certificateItems []apiv1.KeyToPath,
+) ([]apiv1.VolumeMount, []apiv1.Volume) {
 	var volumeMounts []apiv1.VolumeMount
 	var volumes []apiv1.Volume
-	if opts.RegistryUsername != "" && opts.RegistryPassword != "" {
+	if dockerConfig {
 		volumeMounts = append(volumeMounts, apiv1.VolumeMount{
 			Name:      "docker-config",
 			MountPath: "/kaniko/.docker/",
@@ -196,7 +880,125 @@ func getKanikoJob(namespace string, opts *runOptions) *apibatchv1.Job {
 			Name: "docker-config",
 			VolumeSource: apiv1.VolumeSource{
 				Secret: &apiv1.SecretVolumeSource{
-					SecretName: opts.ID,
+					SecretName: secretName,
+					Items:      []apiv1.KeyToPath{{Key: dockerConfigKey, Path: dockerConfigKey}},
+				},
+			},
+		})
+	}
+	if len(certificateItems) != 0 {
+		volumeMounts = append(volumeMounts, apiv1.VolumeMount{
+			Name:      "registry-certificates",
+			MountPath: registryCertificatesDir,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, apiv1.Volume{
+			Name: "registry-certificates",
+			VolumeSource: apiv1.VolumeSource{
+				Secret: &apiv1.SecretVolumeSource{
+					SecretName: secretName,
+					Items:      certificateItems,
+				},
+			},
+		})
+	}
+	return volumeMounts, volumes
+}
+
+func getKanikoJob(namespace string, opts *runOptions) *apibatchv1.Job {
+	args := []string{
+		fmt.Sprintf("--dockerfile=%s", opts.Dockerfile),
+		fmt.Sprintf("--context=%s", opts.Context),
+		fmt.Sprintf("--destination=%s", opts.pushDestination()),
+		fmt.Sprintf("--push-retry=%d", opts.PushRetry),
+		fmt.Sprintf("--verbosity=%s", opts.Verbosity),
+		fmt.Sprintf("--digest-file=%s", apiv1.TerminationMessagePathDefault),
+		fmt.Sprintf("--label=%s=%s", labelInputHash, opts.inputHash()),
+	}
+
+	if opts.Target != "" {
+		args = append(args, fmt.Sprintf("--target=%s", opts.Target))
+	}
+	var nodeSelector map[string]string
+	if opts.Platform != "" {
+		args = append(args, fmt.Sprintf("--custom-platform=%s", opts.Platform))
+		if p, err := parsePlatform(opts.Platform); err == nil {
+			nodeSelector = map[string]string{
+				apiv1.LabelOSStable:   p.OS,
+				apiv1.LabelArchStable: p.Architecture,
+			}
+		}
+	}
+	if opts.Cache {
+		args = append(args, "--cache=true")
+		if opts.CacheRepo != "" {
+			args = append(args, fmt.Sprintf("--cache-repo=%s", opts.CacheRepo))
+		}
+		if opts.CacheTTL != "" {
+			args = append(args, fmt.Sprintf("--cache-ttl=%s", opts.CacheTTL))
+		}
+		if opts.CacheCopyLayers {
+			args = append(args, "--cache-copy-layers=true")
+		}
+		if opts.CacheRunLayers != nil {
+			args = append(args, fmt.Sprintf("--cache-run-layers=%t", *opts.CacheRunLayers))
+		}
+		if opts.CompressedCache != nil {
+			args = append(args, fmt.Sprintf("--compressed-caching=%t", *opts.CompressedCache))
+		}
+	}
+	if opts.CacheClaimName != "" {
+		args = append(args, fmt.Sprintf("--cache-dir=%s", cacheDir))
+	}
+	if opts.SkipUnusedStages {
+		args = append(args, "--skip-unused-stages")
+	}
+
+	buildArgKeys := make([]string, 0, len(opts.BuildArg))
+	for k := range opts.BuildArg {
+		buildArgKeys = append(buildArgKeys, k)
+	}
+	sort.Strings(buildArgKeys)
+	for _, k := range buildArgKeys {
+		args = append(args, fmt.Sprintf("--build-arg=%s=%s", k, opts.BuildArg[k]))
+	}
+
+	imageLabels := getImageLabels(opts, time.Now())
+	imageLabelKeys := make([]string, 0, len(imageLabels))
+	for k := range imageLabels {
+		imageLabelKeys = append(imageLabelKeys, k)
+	}
+	sort.Strings(imageLabelKeys)
+	for _, k := range imageLabelKeys {
+		args = append(args, fmt.Sprintf("--label=%s=%s", k, imageLabels[k]))
+	}
+
+	args = append(args, getRegistryMirrorArgs(opts.RegistryMirrors, opts.RegistryMap)...)
+	for _, r := range opts.InsecureRegistries {
+		args = append(args, fmt.Sprintf("--insecure-registry=%s", r))
+	}
+	if opts.SkipTLSVerify {
+		args = append(args, "--skip-tls-verify")
+	}
+	if opts.SkipTLSVerifyPull {
+		args = append(args, "--skip-tls-verify-pull")
+	}
+	certificateArgs, certificateItems := getRegistryCertificateArgs(opts.RegistryCertificates)
+	args = append(args, certificateArgs...)
+
+	volumeMounts, volumes := getRegistrySecretVolumes(opts.ID, opts.hasCredentials(), certificateItems)
+	if opts.CacheClaimName != "" {
+		volumeMounts = append(volumeMounts, apiv1.VolumeMount{
+			Name:      cacheVolumeName,
+			MountPath: cacheDir,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, apiv1.Volume{
+			Name: cacheVolumeName,
+			VolumeSource: apiv1.VolumeSource{
+				PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{
+					ClaimName: opts.CacheClaimName,
+					ReadOnly:  true,
 				},
 			},
 		})
@@ -204,19 +1006,29 @@ func getKanikoJob(namespace string, opts *runOptions) *apibatchv1.Job {
 
 	return &apibatchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      opts.ID,
+			Namespace:   namespace,
+			Name:        opts.ID,
+			Labels:      getLabels(opts),
+			Annotations: getAnnotations(opts),
 		},
 		Spec: apibatchv1.JobSpec{
 			BackoffLimit:            pointer.Int32(0),
 			TTLSecondsAfterFinished: pointer.Int32(3600),
 			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: getAnnotations(opts, opts.PodAnnotations),
+					Labels:      getLabels(opts, opts.PodLabels),
+				},
 				Spec: apiv1.PodSpec{
+					ServiceAccountName:           opts.ServiceAccountName,
+					AutomountServiceAccountToken: opts.AutomountServiceAccountToken,
+					NodeSelector:                 nodeSelector,
 					Containers: []apiv1.Container{
 						{
-							Name:         "build",
+							Name:         buildContainerName,
 							Image:        kanikoImage,
 							Args:         args,
+							Env:          opts.Proxy.env(),
 							VolumeMounts: volumeMounts,
 						},
 					},
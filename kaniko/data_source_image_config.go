@@ -0,0 +1,213 @@
+package kaniko
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &imageConfigDataSource{}
+	_ datasource.DataSourceWithConfigure = &imageConfigDataSource{}
+)
+
+type imageConfigDataSourceModel struct {
+	Reference types.String `tfsdk:"reference"`
+	Platform  types.String `tfsdk:"platform"`
+
+	Digest       types.String `tfsdk:"digest"`
+	Entrypoint   types.List   `tfsdk:"entrypoint"`
+	Cmd          types.List   `tfsdk:"cmd"`
+	Env          types.List   `tfsdk:"env"`
+	Labels       types.Map    `tfsdk:"labels"`
+	User         types.String `tfsdk:"user"`
+	Workdir      types.String `tfsdk:"workdir"`
+	ExposedPorts types.List   `tfsdk:"exposed_ports"`
+	Created      types.String `tfsdk:"created"`
+	LayerDigests types.List   `tfsdk:"layer_digests"`
+}
+
+// NewImageConfigDataSource is a helper function to simplify the provider implementation.
+func NewImageConfigDataSource() datasource.DataSource {
+	return &imageConfigDataSource{}
+}
+
+// imageConfigDataSource is the data source implementation.
+type imageConfigDataSource struct {
+	providerData *providerData
+}
+
+// Metadata returns the data source type name.
+func (d *imageConfigDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_image_config"
+}
+
+// Schema defines the schema for the data source.
+func (d *imageConfigDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Inspect the config of an image from the registry.`,
+		Attributes: map[string]schema.Attribute{
+			"reference": schema.StringAttribute{
+				Required:    true,
+				Description: "Image reference to inspect, e.g. registry/repository:tag.",
+				Validators: []validator.String{
+					ImageReferenceValidator(),
+				},
+			},
+			"platform": schema.StringAttribute{
+				Optional: true,
+				Description: "Platform to select if the reference is an index, e.g. linux/arm64. " +
+					"(default \"linux/amd64\")",
+			},
+			"digest": schema.StringAttribute{
+				Computed:    true,
+				Description: "Digest of the image.",
+			},
+			"entrypoint": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Entrypoint of the image.",
+			},
+			"cmd": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Default command of the image.",
+			},
+			"env": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Environment variables of the image, in the form of KEY=VALUE.",
+			},
+			"labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Labels of the image.",
+			},
+			"user": schema.StringAttribute{
+				Computed:    true,
+				Description: "User the image runs as.",
+			},
+			"workdir": schema.StringAttribute{
+				Computed:    true,
+				Description: "Working directory of the image.",
+			},
+			"exposed_ports": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Exposed ports of the image, e.g. 80/tcp.",
+			},
+			"created": schema.StringAttribute{
+				Computed:    true,
+				Description: "Creation time of the image in RFC3339 format.",
+			},
+			"layer_digests": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Digests of the image layers, from the base layer.",
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *imageConfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state imageConfigDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	img, err := getImage(ctx, d.providerData.registryOptions(),
+		state.Reference.ValueString(), state.Platform.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("failed to get image", err.Error())
+		return
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		resp.Diagnostics.AddError("failed to get image digest", err.Error())
+		return
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		resp.Diagnostics.AddError("failed to get image config", err.Error())
+		return
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		resp.Diagnostics.AddError("failed to get image manifest", err.Error())
+		return
+	}
+
+	exposedPorts := make([]string, 0, len(cfg.Config.ExposedPorts))
+	for p := range cfg.Config.ExposedPorts {
+		exposedPorts = append(exposedPorts, p)
+	}
+	sort.Strings(exposedPorts)
+
+	layerDigests := make([]string, 0, len(manifest.Layers))
+	for _, l := range manifest.Layers {
+		layerDigests = append(layerDigests, l.Digest.String())
+	}
+
+	state.Digest = types.StringValue(digest.String())
+	state.User = types.StringValue(cfg.Config.User)
+	state.Workdir = types.StringValue(cfg.Config.WorkingDir)
+	state.Created = types.StringNull()
+	if !cfg.Created.IsZero() {
+		state.Created = types.StringValue(cfg.Created.UTC().Format(time.RFC3339))
+	}
+
+	for _, v := range []struct {
+		target *types.List
+		value  []string
+	}{
+		{target: &state.Entrypoint, value: cfg.Config.Entrypoint},
+		{target: &state.Cmd, value: cfg.Config.Cmd},
+		{target: &state.Env, value: cfg.Config.Env},
+		{target: &state.ExposedPorts, value: exposedPorts},
+		{target: &state.LayerDigests, value: layerDigests},
+	} {
+		var listDiags diag.Diagnostics
+		*v.target, listDiags = types.ListValueFrom(ctx, types.StringType, v.value)
+		resp.Diagnostics.Append(listDiags...)
+	}
+	state.Labels, diags = types.MapValueFrom(ctx, types.StringType, cfg.Config.Labels)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *imageConfigDataSource) Configure(
+	_ context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	var ok bool
+	d.providerData, ok = req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
+	}
+}
@@ -0,0 +1,127 @@
+package kaniko
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &imageDigestDataSource{}
+	_ datasource.DataSourceWithConfigure = &imageDigestDataSource{}
+)
+
+type imageDigestDataSourceModel struct {
+	Reference types.String `tfsdk:"reference"`
+	Digest    types.String `tfsdk:"digest"`
+	MediaType types.String `tfsdk:"media_type"`
+	Platforms types.List   `tfsdk:"platforms"`
+	Size      types.Int64  `tfsdk:"size"`
+}
+
+// NewImageDigestDataSource is a helper function to simplify the provider implementation.
+func NewImageDigestDataSource() datasource.DataSource {
+	return &imageDigestDataSource{}
+}
+
+// imageDigestDataSource is the data source implementation.
+type imageDigestDataSource struct {
+	providerData *providerData
+}
+
+// Metadata returns the data source type name.
+func (d *imageDigestDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_image_digest"
+}
+
+// Schema defines the schema for the data source.
+func (d *imageDigestDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Resolve an image reference to its digest from the registry.`,
+		Attributes: map[string]schema.Attribute{
+			"reference": schema.StringAttribute{
+				Required:    true,
+				Description: "Image reference to resolve, e.g. registry/repository:tag.",
+				Validators: []validator.String{
+					ImageReferenceValidator(),
+				},
+			},
+			"digest": schema.StringAttribute{
+				Computed:    true,
+				Description: "Digest of the image or image index.",
+			},
+			"media_type": schema.StringAttribute{
+				Computed:    true,
+				Description: "Media type of the manifest.",
+			},
+			"platforms": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Platforms of the image, or of the images in the index.",
+			},
+			"size": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Size of the manifest in bytes.",
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *imageDigestDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state imageDigestDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	desc, err := getDescriptor(ctx, d.providerData.registryOptions(), state.Reference.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("failed to resolve image", err.Error())
+		return
+	}
+
+	platforms, err := getPlatforms(desc)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to get image platforms", err.Error())
+		return
+	}
+
+	state.Digest = types.StringValue(desc.Digest.String())
+	state.MediaType = types.StringValue(string(desc.MediaType))
+	state.Size = types.Int64Value(desc.Size)
+	state.Platforms, diags = types.ListValueFrom(ctx, types.StringType, platforms)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *imageDigestDataSource) Configure(
+	_ context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	var ok bool
+	d.providerData, ok = req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
+	}
+}
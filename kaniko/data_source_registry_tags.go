@@ -0,0 +1,200 @@
+package kaniko
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/seal-io/terraform-provider-kaniko/utils"
+)
+
+const (
+	tagsSortBySemver  = "semver"
+	tagsSortByLexical = "lexical"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &registryTagsDataSource{}
+	_ datasource.DataSourceWithConfigure = &registryTagsDataSource{}
+)
+
+type registryTagsDataSourceModel struct {
+	Repository types.String `tfsdk:"repository"`
+	Filter     types.String `tfsdk:"filter"`
+	SortBy     types.String `tfsdk:"sort_by"`
+
+	Tags   types.List   `tfsdk:"tags"`
+	Latest types.String `tfsdk:"latest"`
+}
+
+// NewRegistryTagsDataSource is a helper function to simplify the provider implementation.
+func NewRegistryTagsDataSource() datasource.DataSource {
+	return &registryTagsDataSource{}
+}
+
+// registryTagsDataSource is the data source implementation.
+type registryTagsDataSource struct {
+	providerData *providerData
+}
+
+// Metadata returns the data source type name.
+func (d *registryTagsDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_registry_tags"
+}
+
+// Schema defines the schema for the data source.
+func (d *registryTagsDataSource) Schema(
+	_ context.Context,
+	_ datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		Description: `List the tags of a repository from the registry.`,
+		Attributes: map[string]schema.Attribute{
+			"repository": schema.StringAttribute{
+				Required:    true,
+				Description: "Repository to list the tags of, e.g. registry/repository.",
+			},
+			"filter": schema.StringAttribute{
+				Optional:    true,
+				Description: "Regular expression the tags must match.",
+			},
+			"sort_by": schema.StringAttribute{
+				Optional: true,
+				Description: "Order of the tags, semver drops the tags which are not semantic versions " +
+					"(semver, lexical) (default semver)",
+				Validators: []validator.String{
+					OneOfValidator(tagsSortBySemver, tagsSortByLexical),
+				},
+			},
+			"tags": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Matched tags, from the oldest to the newest.",
+			},
+			"latest": schema.StringAttribute{
+				Computed:    true,
+				Description: "Newest matched tag, empty if none.",
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *registryTagsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state registryTagsDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var filter *regexp.Regexp
+	if !state.Filter.IsNull() {
+		var err error
+		filter, err = regexp.Compile(state.Filter.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("filter"), "invalid regular expression", err.Error())
+			return
+		}
+	}
+
+	registryOpts := d.providerData.registryOptions()
+	repo, err := registryOpts.parseRepository(state.Repository.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("repository"), "invalid repository", err.Error())
+		return
+	}
+	all, err := remote.List(repo, registryOpts.remoteOptions(ctx)...)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to list tags", err.Error())
+		return
+	}
+
+	tags := make([]string, 0, len(all))
+	for _, t := range all {
+		if filter != nil && !filter.MatchString(t) {
+			continue
+		}
+		tags = append(tags, t)
+	}
+
+	if state.SortBy.IsNull() || state.SortBy.ValueString() == tagsSortBySemver {
+		tags = sortSemverTags(tags)
+	} else {
+		sort.Strings(tags)
+	}
+
+	state.Latest = types.StringValue("")
+	if len(tags) != 0 {
+		state.Latest = types.StringValue(tags[len(tags)-1])
+	}
+	state.Tags, diags = types.ListValueFrom(ctx, types.StringType, tags)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *registryTagsDataSource) Configure(
+	_ context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	var ok bool
+	d.providerData, ok = req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
+	}
+}
+
+// sortSemverTags returns the tags which are semantic versions in ascending order.
+func sortSemverTags(tags []string) []string {
+	type semverTag struct {
+		tag     string
+		version utils.Semver
+	}
+
+	versions := make([]semverTag, 0, len(tags))
+	for _, t := range tags {
+		v, ok := utils.ParseSemver(t)
+		if !ok {
+			continue
+		}
+		versions = append(versions, semverTag{tag: t, version: v})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if c := versions[i].version.Compare(versions[j].version); c != 0 {
+			return c < 0
+		}
+		// Keep the order stable between v1.0.0 and 1.0.0.
+		return versions[i].tag < versions[j].tag
+	})
+
+	sorted := make([]string, 0, len(versions))
+	for _, v := range versions {
+		sorted = append(sorted, v.tag)
+	}
+	return sorted
+}
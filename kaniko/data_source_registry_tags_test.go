@@ -0,0 +1,51 @@
+package kaniko
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortSemverTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{
+			name:     "no tags",
+			tags:     nil,
+			expected: []string{},
+		},
+		{
+			name:     "non semver tags are dropped",
+			tags:     []string{"latest", "main", "1.0", "sha-0123", "1.0.0"},
+			expected: []string{"1.0.0"},
+		},
+		{
+			name: "pre-release ordering",
+			tags: []string{"1.0.0", "1.0.0-rc.10", "1.0.0-beta", "1.0.0-rc.2", "1.0.0-alpha.1", "1.0.0-alpha"},
+			expected: []string{
+				"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-beta", "1.0.0-rc.2", "1.0.0-rc.10", "1.0.0",
+			},
+		},
+		{
+			name:     "numeric ordering",
+			tags:     []string{"1.10.0", "1.9.0", "10.0.0", "2.0.0"},
+			expected: []string{"1.9.0", "1.10.0", "2.0.0", "10.0.0"},
+		},
+		{
+			name:     "v prefix and build metadata",
+			tags:     []string{"v1.0.0", "1.0.0+2", "1.0.0", "v0.9.0"},
+			expected: []string{"v0.9.0", "1.0.0", "1.0.0+2", "v1.0.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted := sortSemverTags(tc.tags)
+			if !reflect.DeepEqual(sorted, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, sorted)
+			}
+		})
+	}
+}
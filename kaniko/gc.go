@@ -0,0 +1,85 @@
+package kaniko
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// gcOrphans deletes the jobs, secrets and config maps created by the provider
+// which are older than the given age, they are left behind when terraform
+// is interrupted before the build cleans up. Running jobs and the secrets
+// of the remaining jobs are kept, as a concurrent or later run may adopt them.
+func gcOrphans(ctx context.Context, restConfig *rest.Config, namespace string, olderThan time.Duration) error {
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	var (
+		listOpts    = metav1.ListOptions{LabelSelector: labelManagedBy + "=" + managedBy}
+		propagation = metav1.DeletePropagationBackground
+		deleteOpts  = metav1.DeleteOptions{PropagationPolicy: &propagation}
+		expired     = func(o metav1.Object) bool {
+			return time.Since(o.GetCreationTimestamp().Time) > olderThan
+		}
+	)
+
+	jobs, err := clientSet.BatchV1().Jobs(namespace).List(ctx, listOpts)
+	if err != nil {
+		return err
+	}
+	remaining := make(map[string]struct{}, len(jobs.Items))
+	for i := range jobs.Items {
+		name := jobs.Items[i].Name
+		if !expired(&jobs.Items[i]) || jobs.Items[i].Status.Active > 0 {
+			remaining[name] = struct{}{}
+			continue
+		}
+		err = clientSet.BatchV1().Jobs(namespace).Delete(ctx, name, deleteOpts)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		tflog.Info(ctx, "removed orphaned kaniko job", map[string]any{"namespace": namespace, "name": name})
+	}
+
+	secrets, err := clientSet.CoreV1().Secrets(namespace).List(ctx, listOpts)
+	if err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		// The secret of a build is named after its job.
+		if _, ok := remaining[secrets.Items[i].Name]; ok || !expired(&secrets.Items[i]) {
+			continue
+		}
+		name := secrets.Items[i].Name
+		err = clientSet.CoreV1().Secrets(namespace).Delete(ctx, name, deleteOpts)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		tflog.Info(ctx, "removed orphaned kaniko secret", map[string]any{"namespace": namespace, "name": name})
+	}
+
+	configMaps, err := clientSet.CoreV1().ConfigMaps(namespace).List(ctx, listOpts)
+	if err != nil {
+		return err
+	}
+	for i := range configMaps.Items {
+		if !expired(&configMaps.Items[i]) {
+			continue
+		}
+		name := configMaps.Items[i].Name
+		err = clientSet.CoreV1().ConfigMaps(namespace).Delete(ctx, name, deleteOpts)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		tflog.Info(ctx, "removed orphaned kaniko config map", map[string]any{"namespace": namespace, "name": name})
+	}
+
+	return nil
+}
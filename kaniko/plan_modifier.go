@@ -3,44 +3,146 @@ package kaniko
 import (
 	"context"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
-// BuildIDModifier returns a plan modifier set build id to unknown string to the planned value.
-func BuildIDModifier() planmodifier.String {
-	return buildIDModifier{}
+// buildOutputAttributes are the computed attributes produced by a build.
+var buildOutputAttributes = map[string]struct{}{
+	"build_id":         {},
+	"digest":           {},
+	"platform_digests": {},
 }
 
-// buildIDModifier implements the plan modifier.
-type buildIDModifier struct{}
+// BuildOutputModifier returns a plan modifier set a build output to unknown string to the planned value
+// while the build will run, or keep the prior state value otherwise.
+func BuildOutputModifier() planmodifier.String {
+	return buildOutputModifier{}
+}
+
+// buildOutputModifier implements the plan modifier.
+type buildOutputModifier struct{}
 
 // Description returns a human-readable description of the plan modifier.
-func (m buildIDModifier) Description(_ context.Context) string {
-	return "Set build id to unknown string while need always run for every plan."
+func (m buildOutputModifier) Description(_ context.Context) string {
+	return "Set build output to unknown string while need always run for every plan or any build input changes, " +
+		"otherwise use the prior state value."
 }
 
 // MarkdownDescription returns a markdown description of the plan modifier.
-func (m buildIDModifier) MarkdownDescription(_ context.Context) string {
-	return "Set build id to unknown string while need always run for every plan."
+func (m buildOutputModifier) MarkdownDescription(_ context.Context) string {
+	return "Set build output to unknown string while need always run for every plan or any build input changes, " +
+		"otherwise use the prior state value."
 }
 
 // PlanModifyString implements the plan modification logic.
-func (m buildIDModifier) PlanModifyString(
-	_ context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse,
+func (m buildOutputModifier) PlanModifyString(
+	ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse,
 ) {
-	var (
-		ctx  = context.Background()
-		plan imageResourceModel
-	)
+	// Nothing to keep while creating or destroying.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
 
-	diags := req.Plan.Get(ctx, &plan)
+	run, diags := buildWillRun(ctx, req.Plan, req.State)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if !plan.AlwaysRun.IsNull() && plan.AlwaysRun.ValueBool() {
+	// An imported image gets the build id of its inputs while they are set.
+	if run || req.Path.Equal(path.Root("build_id")) && req.StateValue.IsNull() {
 		resp.PlanValue = types.StringUnknown()
+		return
+	}
+
+	resp.PlanValue = req.StateValue
+}
+
+// BuildOutputMapModifier returns a plan modifier set a build output to unknown map to the planned value
+// while the build will run, or keep the prior state value otherwise.
+func BuildOutputMapModifier() planmodifier.Map {
+	return buildOutputModifier{}
+}
+
+// PlanModifyMap implements the plan modification logic.
+func (m buildOutputModifier) PlanModifyMap(
+	ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse,
+) {
+	// Nothing to keep while creating or destroying.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	run, diags := buildWillRun(ctx, req.Plan, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if run {
+		resp.PlanValue = types.MapUnknown(req.PlanValue.ElementType(ctx))
+		return
+	}
+
+	resp.PlanValue = req.StateValue
+}
+
+// buildWillRun returns whether the build runs for the plan,
+// that is always run is set or any build input of a built image changes.
+func buildWillRun(ctx context.Context, plan tfsdk.Plan, state tfsdk.State) (bool, diag.Diagnostics) {
+	var model imageResourceModel
+	diags := plan.Get(ctx, &model)
+	if diags.HasError() {
+		return false, diags
+	}
+
+	if !model.AlwaysRun.IsNull() && model.AlwaysRun.ValueBool() {
+		return true, diags
+	}
+
+	// An imported image has no build id, it is taken as built from the configured inputs
+	// unless it is to be pushed elsewhere.
+	var prior imageResourceModel
+	diags.Append(state.Get(ctx, &prior)...)
+	if diags.HasError() {
+		return false, diags
+	}
+	if prior.BuildID.IsNull() {
+		return !model.Destination.Equal(prior.Destination), diags
+	}
+
+	planInputs, err := tftypes.Transform(plan.Raw, nullBuildOutputs)
+	if err != nil {
+		diags.AddError("failed to compare build inputs", err.Error())
+		return false, diags
+	}
+	stateInputs, err := tftypes.Transform(state.Raw, nullBuildOutputs)
+	if err != nil {
+		diags.AddError("failed to compare build inputs", err.Error())
+		return false, diags
+	}
+
+	return !planInputs.Equal(stateInputs), diags
+}
+
+// nullBuildOutputs nulls the build outputs of a resource value,
+// so that only the build inputs are left to compare.
+func nullBuildOutputs(p *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+	steps := p.Steps()
+	if len(steps) != 1 {
+		return v, nil
+	}
+	attr, ok := steps[0].(tftypes.AttributeName)
+	if !ok {
+		return v, nil
+	}
+	if _, ok = buildOutputAttributes[string(attr)]; !ok {
+		return v, nil
 	}
+	return tftypes.NewValue(v.Type(), nil), nil
 }
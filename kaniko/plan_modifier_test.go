@@ -0,0 +1,331 @@
+package kaniko
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func imageResourceSchema(t *testing.T) schema.Schema {
+	t.Helper()
+
+	var resp resource.SchemaResponse
+	(&imageResource{}).Schema(context.Background(), resource.SchemaRequest{}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("invalid schema: %v", resp.Diagnostics)
+	}
+	return resp.Schema
+}
+
+func imageResourceValue(t *testing.T, s schema.Schema, m *imageResourceModel) tftypes.Value {
+	t.Helper()
+
+	ctx := context.Background()
+	state := tfsdk.State{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+	if m == nil {
+		return state.Raw
+	}
+	if diags := state.Set(ctx, m); diags.HasError() {
+		t.Fatalf("invalid model: %v", diags)
+	}
+	return state.Raw
+}
+
+func baseImageResourceModel() imageResourceModel {
+	m := imageResourceModel{
+		BuildID:     types.StringValue("kaniko-0123456789abcdef"),
+		Digest:      types.StringValue("sha256:0123"),
+		Context:     types.StringValue("git://github.com/seal-io/simple-web-service"),
+		Destination: types.StringValue("docker.io/seal-io/test:1"),
+	}
+
+	// The zero values of lists and maps have no element type, all of them are of strings but the blocks.
+	v := reflect.ValueOf(&m).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch v.Field(i).Interface().(type) {
+		case types.List:
+			v.Field(i).Set(reflect.ValueOf(types.ListNull(types.StringType)))
+		case types.Map:
+			v.Field(i).Set(reflect.ValueOf(types.MapNull(types.StringType)))
+		}
+	}
+	m.RegistryCertificates = types.ListNull(types.ObjectType{AttrTypes: map[string]attr.Type{
+		"host":   types.StringType,
+		"ca_pem": types.StringType,
+	}})
+	return m
+}
+
+func TestBuildOutputModifier(t *testing.T) {
+	s := imageResourceSchema(t)
+
+	testCases := []struct {
+		name    string
+		state   func() *imageResourceModel
+		plan    func() *imageResourceModel
+		unknown bool
+	}{
+		{
+			name: "unchanged inputs keep the state values",
+			state: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				return &m
+			},
+			plan: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				return &m
+			},
+		},
+		{
+			name: "changed input plans unknown",
+			state: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				return &m
+			},
+			plan: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				m.Context = types.StringValue("git://github.com/seal-io/another-web-service")
+				return &m
+			},
+			unknown: true,
+		},
+		{
+			name: "changed triggers plan unknown",
+			state: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				return &m
+			},
+			plan: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				m.Triggers = stringMapValue(map[string]string{"commit": "abc"})
+				return &m
+			},
+			unknown: true,
+		},
+		{
+			name: "always run plans unknown",
+			state: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				m.AlwaysRun = types.BoolValue(true)
+				return &m
+			},
+			plan: func() *imageResourceModel {
+				m := baseImageResourceModel()
+				m.AlwaysRun = types.BoolValue(true)
+				return &m
+			},
+			unknown: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			state := tfsdk.State{Schema: s, Raw: imageResourceValue(t, s, tc.state())}
+			plan := tfsdk.Plan{Schema: s, Raw: imageResourceValue(t, s, tc.plan())}
+
+			stringReq := planmodifier.StringRequest{
+				State:      state,
+				Plan:       plan,
+				StateValue: tc.state().Digest,
+				PlanValue:  types.StringUnknown(),
+			}
+			stringResp := planmodifier.StringResponse{PlanValue: stringReq.PlanValue}
+			BuildOutputModifier().PlanModifyString(ctx, stringReq, &stringResp)
+			if stringResp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", stringResp.Diagnostics)
+			}
+			if got := stringResp.PlanValue.IsUnknown(); got != tc.unknown {
+				t.Errorf("expected unknown %t, got %s", tc.unknown, stringResp.PlanValue)
+			}
+			if !tc.unknown && !stringResp.PlanValue.Equal(stringReq.StateValue) {
+				t.Errorf("expected the state value %s, got %s", stringReq.StateValue, stringResp.PlanValue)
+			}
+
+			digests := stringMapValue(map[string]string{"linux/amd64": "sha256:0123"})
+			mapReq := planmodifier.MapRequest{
+				State:      state,
+				Plan:       plan,
+				StateValue: digests,
+				PlanValue:  types.MapUnknown(types.StringType),
+			}
+			mapResp := planmodifier.MapResponse{PlanValue: mapReq.PlanValue}
+			BuildOutputMapModifier().PlanModifyMap(ctx, mapReq, &mapResp)
+			if mapResp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", mapResp.Diagnostics)
+			}
+			if got := mapResp.PlanValue.IsUnknown(); got != tc.unknown {
+				t.Errorf("expected unknown %t, got %s", tc.unknown, mapResp.PlanValue)
+			}
+		})
+	}
+}
+
+func TestBuildOutputModifierCreateAndDestroy(t *testing.T) {
+	s := imageResourceSchema(t)
+	m := baseImageResourceModel()
+
+	testCases := []struct {
+		name  string
+		state tftypes.Value
+		plan  tftypes.Value
+	}{
+		{
+			name:  "create",
+			state: imageResourceValue(t, s, nil),
+			plan:  imageResourceValue(t, s, &m),
+		},
+		{
+			name:  "destroy",
+			state: imageResourceValue(t, s, &m),
+			plan:  imageResourceValue(t, s, nil),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := planmodifier.StringRequest{
+				State:     tfsdk.State{Schema: s, Raw: tc.state},
+				Plan:      tfsdk.Plan{Schema: s, Raw: tc.plan},
+				PlanValue: types.StringUnknown(),
+			}
+			resp := planmodifier.StringResponse{PlanValue: req.PlanValue}
+			BuildOutputModifier().PlanModifyString(context.Background(), req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if !resp.PlanValue.IsUnknown() {
+				t.Errorf("expected the plan value untouched, got %s", resp.PlanValue)
+			}
+		})
+	}
+}
+
+func TestBuildWillRun(t *testing.T) {
+	s := imageResourceSchema(t)
+	state := baseImageResourceModel()
+
+	// The build outputs are not build inputs.
+	plan := baseImageResourceModel()
+	plan.BuildID = types.StringUnknown()
+	plan.Digest = types.StringUnknown()
+	plan.PlatformDigests = types.MapUnknown(types.StringType)
+
+	run, diags := buildWillRun(context.Background(),
+		tfsdk.Plan{Schema: s, Raw: imageResourceValue(t, s, &plan)},
+		tfsdk.State{Schema: s, Raw: imageResourceValue(t, s, &state)})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if run {
+		t.Error("expected no build while only the build outputs differ")
+	}
+}
+
+func TestNullBuildOutputs(t *testing.T) {
+	s := imageResourceSchema(t)
+	m := baseImageResourceModel()
+
+	v, err := tftypes.Transform(imageResourceValue(t, s, &m), nullBuildOutputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var attrs map[string]tftypes.Value
+	if err = v.As(&attrs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name := range buildOutputAttributes {
+		if !attrs[name].IsNull() {
+			t.Errorf("expected %s to be null, got %s", name, attrs[name])
+		}
+	}
+	if attrs["context"].IsNull() || attrs["destination"].IsNull() {
+		t.Error("expected the build inputs to be kept")
+	}
+}
+
+func TestBuildOutputModifierImported(t *testing.T) {
+	s := imageResourceSchema(t)
+
+	// An imported image has no build id nor inputs but its destination.
+	state := baseImageResourceModel()
+	state.BuildID = types.StringNull()
+	state.Context = types.StringNull()
+
+	testCases := []struct {
+		name    string
+		plan    func() imageResourceModel
+		unknown bool
+	}{
+		{
+			name: "configured inputs keep the imported image",
+			plan: baseImageResourceModel,
+		},
+		{
+			name: "changed destination plans unknown",
+			plan: func() imageResourceModel {
+				m := baseImageResourceModel()
+				m.Destination = types.StringValue("docker.io/seal-io/test:2")
+				return m
+			},
+			unknown: true,
+		},
+		{
+			name: "always run plans unknown",
+			plan: func() imageResourceModel {
+				m := baseImageResourceModel()
+				m.AlwaysRun = types.BoolValue(true)
+				return m
+			},
+			unknown: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			plan := tc.plan()
+			req := planmodifier.StringRequest{
+				State: tfsdk.State{Schema: s, Raw: imageResourceValue(t, s, &state)},
+				Plan:  tfsdk.Plan{Schema: s, Raw: imageResourceValue(t, s, &plan)},
+			}
+
+			digestReq := req
+			digestReq.Path = path.Root("digest")
+			digestReq.StateValue = state.Digest
+			digestReq.PlanValue = types.StringUnknown()
+			digestResp := planmodifier.StringResponse{PlanValue: digestReq.PlanValue}
+			BuildOutputModifier().PlanModifyString(ctx, digestReq, &digestResp)
+			if digestResp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", digestResp.Diagnostics)
+			}
+			if got := digestResp.PlanValue.IsUnknown(); got != tc.unknown {
+				t.Errorf("expected unknown digest %t, got %s", tc.unknown, digestResp.PlanValue)
+			}
+
+			// The build id of the inputs is always set after importing.
+			buildIDReq := req
+			buildIDReq.Path = path.Root("build_id")
+			buildIDReq.StateValue = state.BuildID
+			buildIDReq.PlanValue = types.StringUnknown()
+			buildIDResp := planmodifier.StringResponse{PlanValue: buildIDReq.PlanValue}
+			BuildOutputModifier().PlanModifyString(ctx, buildIDReq, &buildIDResp)
+			if buildIDResp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", buildIDResp.Diagnostics)
+			}
+			if !buildIDResp.PlanValue.IsUnknown() {
+				t.Errorf("expected unknown build id, got %s", buildIDResp.PlanValue)
+			}
+		})
+	}
+}
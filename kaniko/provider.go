@@ -2,13 +2,20 @@ package kaniko
 
 import (
 	"context"
+	"os"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
 
 	"github.com/seal-io/terraform-provider-kaniko/utils"
 )
@@ -24,6 +31,74 @@ type kanikoProvider struct {
 // kanikoProviderModel describes the provider data model.
 type kanikoProviderModel struct {
 	ConfigPath types.String `tfsdk:"config_path"`
+
+	ServiceAccountName           types.String `tfsdk:"service_account_name"`
+	AutomountServiceAccountToken types.Bool   `tfsdk:"automount_service_account_token"`
+	PodAnnotations               types.Map    `tfsdk:"pod_annotations"`
+	PodLabels                    types.Map    `tfsdk:"pod_labels"`
+	KubernetesAnnotations        types.Map    `tfsdk:"kubernetes_annotations"`
+	KubernetesLabels             types.Map    `tfsdk:"kubernetes_labels"`
+	GCOrphansOlderThan           types.String `tfsdk:"gc_orphans_older_than"`
+	KeepFailedBuilds             types.Bool   `tfsdk:"keep_failed_builds"`
+	RegistryAuths                types.List   `tfsdk:"registry_auth"`
+
+	InsecureRegistries   types.List `tfsdk:"insecure_registries"`
+	SkipTLSVerify        types.Bool `tfsdk:"skip_tls_verify"`
+	SkipTLSVerifyPull    types.Bool `tfsdk:"skip_tls_verify_pull"`
+	RegistryCertificates types.List `tfsdk:"registry_certificates"`
+	RegistryMirrors      types.List `tfsdk:"registry_mirrors"`
+	RegistryMap          types.Map  `tfsdk:"registry_map"`
+
+	HTTPProxy  types.String `tfsdk:"http_proxy"`
+	HTTPSProxy types.String `tfsdk:"https_proxy"`
+	NoProxy    types.String `tfsdk:"no_proxy"`
+}
+
+// providerData is handed to resources and data sources, it holds the kubernetes
+// client config and the defaults that can be overridden per resource.
+type providerData struct {
+	RestConfig *rest.Config
+
+	ServiceAccountName           string
+	AutomountServiceAccountToken *bool
+	PodAnnotations               map[string]string
+	PodLabels                    map[string]string
+	KubernetesAnnotations        map[string]string
+	KubernetesLabels             map[string]string
+	KeepFailedBuilds             bool
+	RegistryAuths                map[string]authn.AuthConfig
+	InsecureRegistries           []string
+	SkipTLSVerify                bool
+	SkipTLSVerifyPull            bool
+	RegistryCertificates         map[string]string
+	RegistryMirrors              []string
+	RegistryMap                  map[string]string
+	Proxy                        proxyOptions
+}
+
+// registryOptions returns the options for the provider to access the registries,
+// with the credentials of the registries by host.
+func (d *providerData) registryOptions() registryOptions {
+	return registryOptions{
+		Auths:                d.RegistryAuths,
+		InsecureRegistries:   d.InsecureRegistries,
+		SkipTLSVerify:        d.SkipTLSVerify,
+		RegistryCertificates: d.RegistryCertificates,
+		Proxy:                d.Proxy,
+	}
+}
+
+// registryCredentials returns the given credentials of a resource, defaulting to environment variables,
+// they only apply to the registries the resource pushes to.
+func registryCredentials(username, password types.String) (string, string) {
+	u, p := os.Getenv("REGISTRY_USERNAME"), os.Getenv("REGISTRY_PASSWORD")
+	if !username.IsNull() {
+		u = username.ValueString()
+	}
+	if !password.IsNull() {
+		p = password.ValueString()
+	}
+	return u, p
 }
 
 func (p *kanikoProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -33,11 +108,137 @@ func (p *kanikoProvider) Metadata(ctx context.Context, req provider.MetadataRequ
 
 func (p *kanikoProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Blocks: map[string]schema.Block{
+			"registry_certificates": registryCertificatesBlock(),
+			"registry_auth":         registryAuthBlock(),
+		},
 		Attributes: map[string]schema.Attribute{
 			"config_path": schema.StringAttribute{
 				Description: "Path to the kube config file.",
 				Optional:    true,
 			},
+			"service_account_name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Default service account to run the build pods as.",
+			},
+			"automount_service_account_token": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Default of whether to mount the service account token into the build pods.",
+			},
+			"pod_annotations": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default annotations to add to the build pods.",
+			},
+			"pod_labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default labels to add to the build pods.",
+			},
+			"kubernetes_annotations": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default annotations to add to the kubernetes objects created for the builds.",
+			},
+			"kubernetes_labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default labels to add to the kubernetes objects created for the builds.",
+			},
+			"gc_orphans_older_than": schema.StringAttribute{
+				Optional: true,
+				Description: "Delete the jobs, secrets and config maps left behind by interrupted builds " +
+					"once they are older than the given duration, e.g. \"24h\".",
+			},
+			"keep_failed_builds": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Default of whether to keep the jobs of failed builds until their TTL expires.",
+			},
+			"insecure_registries": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default registries to access over plain HTTP, e.g. \"registry.local:5000\".",
+			},
+			"skip_tls_verify": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Default of whether to skip verifying the TLS certificates of the registries.",
+			},
+			"skip_tls_verify_pull": schema.BoolAttribute{
+				Optional: true,
+				Description: "Default of whether to skip verifying the TLS certificates of the registries " +
+					"pulled from by the builds.",
+			},
+			"registry_mirrors": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default mirrors to pull the images of docker hub from by the builds, " +
+					"e.g. \"mirror.gcr.io\".",
+			},
+			"registry_map": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Default mirrors to pull the images of other registries from by the builds, " +
+					"e.g. {\"gcr.io\" = \"mirror.local;mirror.example.com\"}.",
+			},
+			"http_proxy": schema.StringAttribute{
+				Optional:    true,
+				Description: "Default proxy of the HTTP requests of the builds and the registry client.",
+			},
+			"https_proxy": schema.StringAttribute{
+				Optional:    true,
+				Description: "Default proxy of the HTTPS requests of the builds and the registry client.",
+			},
+			"no_proxy": schema.StringAttribute{
+				Optional:    true,
+				Description: "Default hosts not to access through the proxies, separated by commas.",
+			},
+		},
+	}
+}
+
+// registryCertificatesBlock returns the schema of the registry certificates block.
+func registryCertificatesBlock() schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		Description: "CA certificates to verify the registries with.",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"host": schema.StringAttribute{
+					Required:    true,
+					Description: "Host of the registry, e.g. \"harbor.local\".",
+				},
+				"ca_pem": schema.StringAttribute{
+					Required:    true,
+					Description: "CA certificate bundle in PEM.",
+				},
+			},
+		},
+	}
+}
+
+// registryAuthBlock returns the schema of the registry credentials block.
+func registryAuthBlock() schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		Description: "Credentials of the registries, they are only sent to the registry of their address, " +
+			"the docker config of the host is used for the other registries.",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"address": schema.StringAttribute{
+					Required:    true,
+					Description: "Address of the registry, e.g. \"harbor.local\" or \"docker.io\".",
+					Validators: []validator.String{
+						RegistryAddressValidator(),
+					},
+				},
+				"username": schema.StringAttribute{
+					Required:    true,
+					Description: "Username for the registry.",
+				},
+				"password": schema.StringAttribute{
+					Required:    true,
+					Sensitive:   true,
+					Description: "Password for the registry.",
+				},
+			},
 		},
 	}
 }
@@ -62,18 +263,62 @@ func (p *kanikoProvider) Configure(
 		return
 	}
 
-	resp.DataSourceData = restConfig
-	resp.ResourceData = restConfig
+	data := &providerData{
+		RestConfig:         restConfig,
+		ServiceAccountName: config.ServiceAccountName.ValueString(),
+		PodAnnotations:     mergeStringMap(nil, config.PodAnnotations),
+		PodLabels:          mergeStringMap(nil, config.PodLabels),
+
+		KubernetesAnnotations: mergeStringMap(nil, config.KubernetesAnnotations),
+		KubernetesLabels:      mergeStringMap(nil, config.KubernetesLabels),
+		KeepFailedBuilds:      config.KeepFailedBuilds.ValueBool(),
+		RegistryAuths:         mergeRegistryAuths(nil, config.RegistryAuths),
+		InsecureRegistries:    stringList(config.InsecureRegistries),
+		SkipTLSVerify:         config.SkipTLSVerify.ValueBool(),
+		SkipTLSVerifyPull:     config.SkipTLSVerifyPull.ValueBool(),
+		RegistryCertificates:  mergeRegistryCertificates(nil, config.RegistryCertificates),
+		RegistryMirrors:       stringList(config.RegistryMirrors),
+		RegistryMap:           mergeStringMap(nil, config.RegistryMap),
+		Proxy: proxyOptions{
+			HTTPProxy:  config.HTTPProxy.ValueString(),
+			HTTPSProxy: config.HTTPSProxy.ValueString(),
+			NoProxy:    config.NoProxy.ValueString(),
+		},
+	}
+	if !config.AutomountServiceAccountToken.IsNull() {
+		data.AutomountServiceAccountToken = pointer.Bool(config.AutomountServiceAccountToken.ValueBool())
+	}
+
+	if !config.GCOrphansOlderThan.IsNull() {
+		olderThan, err := time.ParseDuration(config.GCOrphansOlderThan.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("gc_orphans_older_than"), "invalid duration", err.Error())
+			return
+		}
+		if err = gcOrphans(ctx, restConfig, getNamespace(), olderThan); err != nil {
+			resp.Diagnostics.AddWarning("failed to clean up orphaned builds", err.Error())
+		}
+	}
+
+	resp.DataSourceData = data
+	resp.ResourceData = data
 }
 
 func (p *kanikoProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewImageResource,
+		NewCacheWarmerResource,
+		NewImageIndexResource,
+		NewImageTagResource,
 	}
 }
 
 func (p *kanikoProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewImageDigestDataSource,
+		NewImageConfigDataSource,
+		NewRegistryTagsDataSource,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -0,0 +1,486 @@
+package kaniko
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// registryOptions configures how the provider itself accesses the registries.
+type registryOptions struct {
+	// Credentials of the registries by host, the docker config of the host is used for the other registries.
+	Auths map[string]authn.AuthConfig
+
+	// Registries to access over plain HTTP.
+	InsecureRegistries []string
+	SkipTLSVerify      bool
+	// CA certificates in PEM to verify the registries with by host.
+	RegistryCertificates map[string]string
+	// Proxy settings, the proxy environment variables of the host are used if not set.
+	Proxy proxyOptions
+}
+
+// nameOptions returns the options to parse a reference of the given registry with.
+func (o registryOptions) nameOptions(registry string) []name.Option {
+	for _, r := range o.InsecureRegistries {
+		if r == registry {
+			return []name.Option{name.Insecure}
+		}
+	}
+	return nil
+}
+
+// parseReference parses the given image reference.
+func (o registryOptions) parseReference(s string) (name.Reference, error) {
+	ref, err := name.ParseReference(s)
+	if err != nil {
+		return nil, err
+	}
+	if opts := o.nameOptions(ref.Context().RegistryStr()); opts != nil {
+		return name.ParseReference(s, opts...)
+	}
+	return ref, nil
+}
+
+// parseRepository parses the given repository name.
+func (o registryOptions) parseRepository(s string) (name.Repository, error) {
+	repo, err := name.NewRepository(s)
+	if err != nil {
+		return name.Repository{}, err
+	}
+	if opts := o.nameOptions(repo.RegistryStr()); opts != nil {
+		return name.NewRepository(s, opts...)
+	}
+	return repo, nil
+}
+
+// parseTag parses the given image tag reference.
+func (o registryOptions) parseTag(s string) (name.Tag, error) {
+	tag, err := name.NewTag(s)
+	if err != nil {
+		return name.Tag{}, err
+	}
+	if opts := o.nameOptions(tag.RegistryStr()); opts != nil {
+		return name.NewTag(s, opts...)
+	}
+	return tag, nil
+}
+
+// withAuth returns the options with the given credentials for the registries of the given image references,
+// the credentials are ignored unless both set.
+func (o registryOptions) withAuth(username, password string, references ...string) registryOptions {
+	if username == "" || password == "" {
+		return o
+	}
+
+	auths := make(map[string]authn.AuthConfig, len(o.Auths)+len(references))
+	for k, v := range o.Auths {
+		auths[k] = v
+	}
+	for _, r := range references {
+		ref, err := name.ParseReference(r)
+		if err != nil {
+			continue
+		}
+		auths[ref.Context().RegistryStr()] = authn.AuthConfig{Username: username, Password: password}
+	}
+	o.Auths = auths
+	return o
+}
+
+// remoteOptions returns the options of the registry client,
+// the credentials fall back to the docker config of the host for the registries without ones.
+func (o registryOptions) remoteOptions(ctx context.Context) []remote.Option {
+	opts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.NewMultiKeychain(registryKeychain(o.Auths), authn.DefaultKeychain)),
+	}
+	if t := o.transport(ctx); t != nil {
+		opts = append(opts, remote.WithTransport(t))
+	}
+	return opts
+}
+
+// registryKeychain resolves the credentials of the registries by host, and anonymous for the other registries.
+type registryKeychain map[string]authn.AuthConfig
+
+// Resolve implements authn.Keychain.
+func (k registryKeychain) Resolve(r authn.Resource) (authn.Authenticator, error) {
+	if cfg, ok := k[r.RegistryStr()]; ok {
+		return authn.FromConfig(cfg), nil
+	}
+	return authn.Anonymous, nil
+}
+
+// registryHost returns the host the credentials of the given registry address are keyed by,
+// e.g. "index.docker.io" for "https://index.docker.io/v1/" or "docker.io".
+func registryHost(address string) (string, error) {
+	host := address
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	host, _, _ = strings.Cut(host, "/")
+
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return "", err
+	}
+	return registry.RegistryStr(), nil
+}
+
+// transport returns the transport verifying TLS and using the proxies as configured,
+// or nil to use the default one.
+func (o registryOptions) transport(ctx context.Context) http.RoundTripper {
+	if !o.SkipTLSVerify && len(o.RegistryCertificates) == 0 && !o.Proxy.isSet() {
+		return nil
+	}
+
+	t := remote.DefaultTransport.(*http.Transport).Clone()
+
+	if o.SkipTLSVerify || len(o.RegistryCertificates) != 0 {
+		// The certificates are trusted for all registries, as the transport is shared by them.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			tflog.Warn(ctx, "failed to load the system certificates", map[string]any{"error": err})
+			pool = x509.NewCertPool()
+		}
+		for host, pem := range o.RegistryCertificates {
+			if !pool.AppendCertsFromPEM([]byte(pem)) {
+				tflog.Warn(ctx, "no certificate found in the registry certificate", map[string]any{"host": host})
+			}
+		}
+
+		t.TLSClientConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			RootCAs:            pool,
+			InsecureSkipVerify: o.SkipTLSVerify, //nolint:gosec
+		}
+	}
+
+	if o.Proxy.isSet() {
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  o.Proxy.HTTPProxy,
+			HTTPSProxy: o.Proxy.HTTPSProxy,
+			NoProxy:    o.Proxy.NoProxy,
+		}).ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	return t
+}
+
+// getDescriptor returns the descriptor of the given image reference from the registry.
+func getDescriptor(ctx context.Context, opts registryOptions, reference string) (*remote.Descriptor, error) {
+	ref, err := opts.parseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	return remote.Get(ref, opts.remoteOptions(ctx)...)
+}
+
+// getImage returns the image of the given reference from the registry,
+// the image of the given platform is selected if the reference is an index.
+func getImage(ctx context.Context, opts registryOptions, reference, platform string) (v1.Image, error) {
+	ref, err := opts.parseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteOpts := opts.remoteOptions(ctx)
+	if platform != "" {
+		p, err := v1.ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		remoteOpts = append(remoteOpts, remote.WithPlatform(*p))
+	}
+
+	return remote.Image(ref, remoteOpts...)
+}
+
+// getBuiltDigest returns the digest of the image of the given reference
+// if it was built with the given input hash, or an empty string otherwise,
+// the image of the given platform is checked if the reference is an index.
+func getBuiltDigest(ctx context.Context, opts registryOptions, reference, platform, inputHash string) (string, error) {
+	img, err := getImage(ctx, opts, reference, platform)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Config.Labels[labelInputHash] != inputHash {
+		return "", nil
+	}
+
+	// Report the digest of the index if the reference is one.
+	ref, err := opts.parseReference(reference)
+	if err != nil {
+		return "", err
+	}
+	desc, err := remote.Head(ref, opts.remoteOptions(ctx)...)
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// getPlatforms returns the platforms of the image or the images of the index described.
+func getPlatforms(desc *remote.Descriptor) ([]string, error) {
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		platforms := make([]string, 0, len(manifest.Manifests))
+		for _, m := range manifest.Manifests {
+			if m.Platform == nil {
+				continue
+			}
+			platforms = append(platforms, m.Platform.String())
+		}
+		return platforms, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	platform := v1.Platform{
+		OS:           cfg.OS,
+		Architecture: cfg.Architecture,
+		Variant:      cfg.Variant,
+	}
+	return []string{platform.String()}, nil
+}
+
+// getPlatformDigests returns the digests of the images of the index of the given reference by platform.
+func getPlatformDigests(ctx context.Context, opts registryOptions, reference string) (map[string]string, error) {
+	desc, err := getDescriptor(ctx, opts, reference)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make(map[string]string, len(manifest.Manifests))
+	for _, m := range manifest.Manifests {
+		if m.Platform == nil {
+			continue
+		}
+		digests[m.Platform.String()] = m.Digest.String()
+	}
+	return digests, nil
+}
+
+// parsePlatform parses the given platform in the form os/arch[/variant].
+func parsePlatform(s string) (*v1.Platform, error) {
+	p, err := v1.ParsePlatform(s)
+	if err != nil {
+		return nil, err
+	}
+	if p.OS == "" || p.Architecture == "" {
+		return nil, fmt.Errorf("%q is not a platform, e.g. \"linux/amd64\"", s)
+	}
+	return p, nil
+}
+
+// indexManifest is an image to add to an index.
+type indexManifest struct {
+	Reference string
+	// Platform of the image, read from the image config if nil.
+	Platform *v1.Platform
+}
+
+// pushIndex pushes an index of the given images to the destination and returns its digest,
+// the index is a docker manifest list if the first image is a docker manifest, otherwise an OCI index.
+func pushIndex(
+	ctx context.Context,
+	opts registryOptions,
+	destination string,
+	manifests []indexManifest,
+) (string, error) {
+	dest, err := opts.parseReference(destination)
+	if err != nil {
+		return "", err
+	}
+
+	remoteOpts := opts.remoteOptions(ctx)
+	idx := v1.ImageIndex(empty.Index)
+	for i, m := range manifests {
+		ref, err := opts.parseReference(m.Reference)
+		if err != nil {
+			return "", err
+		}
+		desc, err := remote.Get(ref, remoteOpts...)
+		if err != nil {
+			return "", err
+		}
+		if desc.MediaType.IsIndex() {
+			return "", fmt.Errorf("%s is an index, not an image", m.Reference)
+		}
+		img, err := desc.Image()
+		if err != nil {
+			return "", err
+		}
+
+		platform := m.Platform
+		if platform == nil {
+			cfg, err := img.ConfigFile()
+			if err != nil {
+				return "", err
+			}
+			platform = &v1.Platform{
+				OS:           cfg.OS,
+				Architecture: cfg.Architecture,
+				Variant:      cfg.Variant,
+			}
+		}
+
+		if i == 0 {
+			mediaType := types.OCIImageIndex
+			if desc.MediaType == types.DockerManifestSchema2 {
+				mediaType = types.DockerManifestList
+			}
+			idx = mutate.IndexMediaType(idx, mediaType)
+		}
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: platform},
+		})
+	}
+
+	if err = remote.WriteIndex(dest, idx, remoteOpts...); err != nil {
+		return "", err
+	}
+	digest, err := idx.Digest()
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
+
+// copyImage copies the image or index described to the destination tag,
+// the manifest is tagged only if the destination is in the same repository.
+func copyImage(ctx context.Context, opts registryOptions, desc *remote.Descriptor, destination string) error {
+	dest, err := opts.parseTag(destination)
+	if err != nil {
+		return err
+	}
+
+	remoteOpts := opts.remoteOptions(ctx)
+	if desc.Ref.Context().String() == dest.Context().String() {
+		return remote.Tag(dest, desc, remoteOpts...)
+	}
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+		return remote.WriteIndex(dest, idx, remoteOpts...)
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return err
+	}
+	return remote.Write(dest, img, remoteOpts...)
+}
+
+// getTagDigest returns the digest the given tag points to, or an empty string if the tag does not exist.
+func getTagDigest(ctx context.Context, opts registryOptions, reference string) (string, error) {
+	ref, err := opts.parseTag(reference)
+	if err != nil {
+		return "", err
+	}
+
+	desc, err := remote.Head(ref, opts.remoteOptions(ctx)...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// getRepositoryTags returns the tags of the given repository pointing to the given digest.
+func getRepositoryTags(ctx context.Context, opts registryOptions, repository, digest string) ([]string, error) {
+	repo, err := opts.parseRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteOpts := opts.remoteOptions(ctx)
+	tags, err := remote.List(repo, remoteOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, t := range tags {
+		desc, err := remote.Head(repo.Tag(t), remoteOpts...)
+		if err != nil {
+			var terr *transport.Error
+			if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if desc.Digest.String() == digest {
+			matched = append(matched, repo.Tag(t).String())
+		}
+	}
+	return matched, nil
+}
+
+// deleteTag deletes the given tag from the registry.
+func deleteTag(ctx context.Context, opts registryOptions, reference string) error {
+	ref, err := opts.parseTag(reference)
+	if err != nil {
+		return err
+	}
+	return remote.Delete(ref, opts.remoteOptions(ctx)...)
+}
@@ -0,0 +1,148 @@
+package kaniko
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+func TestRegistryHost(t *testing.T) {
+	testCases := []struct {
+		address  string
+		expected string
+	}{
+		{address: "harbor.local", expected: "harbor.local"},
+		{address: "harbor.local:5000", expected: "harbor.local:5000"},
+		{address: "https://harbor.local/v2/", expected: "harbor.local"},
+		{address: "docker.io", expected: "index.docker.io"},
+		{address: "https://index.docker.io/v1/", expected: "index.docker.io"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			host, err := registryHost(tc.address)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if host != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, host)
+			}
+		})
+	}
+}
+
+func TestRegistryOptionsWithAuth(t *testing.T) {
+	base := registryOptions{
+		Auths: map[string]authn.AuthConfig{
+			"harbor.local": {Username: "harbor", Password: "secret"},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		username   string
+		password   string
+		references []string
+		expected   map[string]authn.AuthConfig
+	}{
+		{
+			name:       "no credentials",
+			references: []string{"ghcr.io/seal-io/test:1"},
+			expected: map[string]authn.AuthConfig{
+				"harbor.local": {Username: "harbor", Password: "secret"},
+			},
+		},
+		{
+			name:       "credentials of the registries of the references",
+			username:   "user",
+			password:   "token",
+			references: []string{"ghcr.io/seal-io/test:1", "seal-io/test:1"},
+			expected: map[string]authn.AuthConfig{
+				"harbor.local":    {Username: "harbor", Password: "secret"},
+				"ghcr.io":         {Username: "user", Password: "token"},
+				"index.docker.io": {Username: "user", Password: "token"},
+			},
+		},
+		{
+			name:       "credentials override the ones of the same registry",
+			username:   "user",
+			password:   "token",
+			references: []string{"harbor.local/library/test:1"},
+			expected: map[string]authn.AuthConfig{
+				"harbor.local": {Username: "user", Password: "token"},
+			},
+		},
+		{
+			name:       "username without password",
+			username:   "user",
+			references: []string{"ghcr.io/seal-io/test:1"},
+			expected: map[string]authn.AuthConfig{
+				"harbor.local": {Username: "harbor", Password: "secret"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := base.withAuth(tc.username, tc.password, tc.references...)
+			if !reflect.DeepEqual(opts.Auths, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, opts.Auths)
+			}
+		})
+	}
+
+	if len(base.Auths) != 1 {
+		t.Errorf("expected the base credentials not to change, got %v", base.Auths)
+	}
+}
+
+func TestRegistryKeychain(t *testing.T) {
+	keychain := registryKeychain{
+		"harbor.local":    {Username: "harbor", Password: "secret"},
+		"index.docker.io": {Username: "hub", Password: "token"},
+	}
+
+	testCases := []struct {
+		reference string
+		expected  authn.AuthConfig
+	}{
+		{
+			reference: "harbor.local/library/test:1",
+			expected:  authn.AuthConfig{Username: "harbor", Password: "secret"},
+		},
+		{
+			reference: "docker.io/library/alpine:3.17",
+			expected:  authn.AuthConfig{Username: "hub", Password: "token"},
+		},
+		{
+			reference: "ghcr.io/seal-io/test:1",
+			expected:  authn.AuthConfig{},
+		},
+		{
+			reference: "harbor.local:5000/library/test:1",
+			expected:  authn.AuthConfig{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.reference, func(t *testing.T) {
+			ref, err := name.ParseReference(tc.reference)
+			if err != nil {
+				t.Fatal(err)
+			}
+			auth, err := keychain.Resolve(ref.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cfg, err := auth.Authorization()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*cfg, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, *cfg)
+			}
+		})
+	}
+}
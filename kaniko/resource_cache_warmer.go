@@ -0,0 +1,302 @@
+package kaniko
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &cacheWarmerResource{}
+	_ resource.ResourceWithConfigure      = &cacheWarmerResource{}
+	_ resource.ResourceWithValidateConfig = &cacheWarmerResource{}
+)
+
+type cacheWarmerResourceModel struct {
+	ID                types.String `tfsdk:"id"`
+	Images            types.List   `tfsdk:"images"`
+	ClaimName         types.String `tfsdk:"claim_name"`
+	ClaimSize         types.String `tfsdk:"claim_size"`
+	ClaimStorageClass types.String `tfsdk:"claim_storage_class"`
+	ClaimAccessMode   types.String `tfsdk:"claim_access_mode"`
+	Verbosity         types.String `tfsdk:"verbosity"`
+	RegistryAuths     types.List   `tfsdk:"registry_auth"`
+}
+
+// NewCacheWarmerResource is a helper function to simplify the provider implementation.
+func NewCacheWarmerResource() resource.Resource {
+	return &cacheWarmerResource{}
+}
+
+// cacheWarmerResource is the resource implementation.
+type cacheWarmerResource struct {
+	providerData *providerData
+}
+
+// Metadata returns the resource type name.
+func (r *cacheWarmerResource) Metadata(
+	_ context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_cache_warmer"
+}
+
+// Schema defines the schema for the resource.
+func (r *cacheWarmerResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Populate a persistent volume with base images for the kaniko_image cache_volume.`,
+		Blocks: map[string]schema.Block{
+			"registry_auth": schema.ListNestedBlock{
+				Description: "Credentials of the registries of the images, merged with the provider ones by address, " +
+					"they are only sent to the registry of their address.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"address": schema.StringAttribute{
+							Required:    true,
+							Description: "Address of the registry, e.g. \"harbor.local\" or \"docker.io\"",
+							Validators: []validator.String{
+								RegistryAddressValidator(),
+							},
+						},
+						"username": schema.StringAttribute{
+							Required:    true,
+							Description: "Username for the registry",
+						},
+						"password": schema.StringAttribute{
+							Required:    true,
+							Sensitive:   true,
+							Description: "Password for the registry",
+						},
+					},
+				},
+			},
+		},
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"images": schema.ListAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Description: "Base images to cache, changing them warms the volume again.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"claim_name": schema.StringAttribute{
+				Required:    true,
+				Description: "Name of the persistent volume claim to populate, it must exist if claim_size is not set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"claim_size": schema.StringAttribute{
+				Optional: true,
+				Description: "Size to create the persistent volume claim with if it does not exist, " +
+					"e.g. \"10Gi\"",
+			},
+			"claim_storage_class": schema.StringAttribute{
+				Optional:    true,
+				Description: "Storage class to create the persistent volume claim with",
+			},
+			"claim_access_mode": schema.StringAttribute{
+				Optional: true,
+				Description: "Access mode to create the persistent volume claim with, " +
+					"ReadWriteOnce only allows the builds and the warmer on the same node " +
+					"(default \"ReadWriteMany\")",
+				Validators: []validator.String{
+					OneOfValidator(cacheClaimAccessModes...),
+				},
+			},
+			"verbosity": schema.StringAttribute{
+				Optional:    true,
+				Description: "Log level (trace, debug, info, warn, error, fatal, panic) (default info)",
+				Validators: []validator.String{
+					OneOfValidator(verbosityLevels...),
+				},
+			},
+		},
+	}
+}
+
+// ValidateConfig rejects the conflicting attribute combinations.
+func (r *cacheWarmerResource) ValidateConfig(
+	ctx context.Context,
+	req resource.ValidateConfigRequest,
+	resp *resource.ValidateConfigResponse,
+) {
+	var config cacheWarmerResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.ClaimSize.IsNull() && !config.ClaimSize.IsUnknown() {
+		if _, err := k8sresource.ParseQuantity(config.ClaimSize.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("claim_size"), "invalid size", err.Error())
+		}
+	}
+	if !config.ClaimStorageClass.IsNull() && config.ClaimSize.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("claim_storage_class"),
+			"conflicting attributes", "claim_storage_class is only used while claim_size is set.")
+	}
+	if !config.ClaimAccessMode.IsNull() && config.ClaimSize.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("claim_access_mode"),
+			"conflicting attributes", "claim_access_mode is only used while claim_size is set.")
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *cacheWarmerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Info(ctx, "Start Create")
+
+	// Retrieve values from plan.
+	var plan cacheWarmerResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.warm(ctx, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("kaniko cache warming failed", err.Error())
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *cacheWarmerResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state cacheWarmerResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *cacheWarmerResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Info(ctx, "Start Update")
+
+	// Retrieve values from plan.
+	var plan cacheWarmerResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.warm(ctx, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("kaniko cache warming failed", err.Error())
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success,
+// the cached images are left in the persistent volume.
+func (r *cacheWarmerResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *cacheWarmerResource) Configure(
+	_ context.Context,
+	req resource.ConfigureRequest,
+	resp *resource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	var ok bool
+	r.providerData, ok = req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
+	}
+}
+
+func (r *cacheWarmerResource) warm(
+	ctx context.Context,
+	plan cacheWarmerResourceModel,
+) (*cacheWarmerResourceModel, error) {
+	verbosity := "debug"
+	if !plan.Verbosity.IsNull() {
+		verbosity = plan.Verbosity.ValueString()
+	}
+
+	var images []string
+	for _, v := range plan.Images.Elements() {
+		if s, ok := v.(types.String); ok && !s.IsNull() {
+			images = append(images, s.ValueString())
+		}
+	}
+
+	registryAuths, err := getImageRegistryAuths(images,
+		mergeRegistryAuths(r.providerData.RegistryAuths, plan.RegistryAuths))
+	if err != nil {
+		return nil, err
+	}
+
+	options := &warmOptions{
+		Images:            images,
+		ClaimName:         plan.ClaimName.ValueString(),
+		ClaimSize:         plan.ClaimSize.ValueString(),
+		ClaimStorageClass: plan.ClaimStorageClass.ValueString(),
+		ClaimAccessMode:   plan.ClaimAccessMode.ValueString(),
+		Verbosity:         verbosity,
+		Proxy:             r.providerData.Proxy,
+
+		RegistryAuths:        registryAuths,
+		InsecureRegistries:   r.providerData.InsecureRegistries,
+		SkipTLSVerifyPull:    r.providerData.SkipTLSVerify || r.providerData.SkipTLSVerifyPull,
+		RegistryCertificates: r.providerData.RegistryCertificates,
+		RegistryMirrors:      r.providerData.RegistryMirrors,
+		RegistryMap:          r.providerData.RegistryMap,
+
+		KeepFailedBuilds:             r.providerData.KeepFailedBuilds,
+		ServiceAccountName:           r.providerData.ServiceAccountName,
+		AutomountServiceAccountToken: r.providerData.AutomountServiceAccountToken,
+		PodAnnotations:               r.providerData.PodAnnotations,
+		PodLabels:                    r.providerData.PodLabels,
+		Annotations:                  r.providerData.KubernetesAnnotations,
+		Labels:                       r.providerData.KubernetesLabels,
+	}
+	options.ID = options.warmerID()
+
+	result, err := kanikoWarm(ctx, r.providerData.RestConfig, options)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.ID = types.StringValue(result.ID)
+	return &plan, nil
+}
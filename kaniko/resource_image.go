@@ -2,42 +2,102 @@ package kaniko
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"k8s.io/client-go/rest"
-
-	"github.com/seal-io/terraform-provider-kaniko/utils"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource              = &imageResource{}
-	_ resource.ResourceWithConfigure = &imageResource{}
+	_ resource.Resource                   = &imageResource{}
+	_ resource.ResourceWithConfigure      = &imageResource{}
+	_ resource.ResourceWithValidateConfig = &imageResource{}
+	_ resource.ResourceWithImportState    = &imageResource{}
+	_ resource.ResourceWithUpgradeState   = &imageResource{}
 )
 
 type imageResourceModel struct {
-	BuildID     types.String `tfsdk:"build_id"`
-	GitUsername types.String `tfsdk:"git_username"`
-	GitPassword types.String `tfsdk:"git_password"`
-	AlwaysRun   types.Bool   `tfsdk:"always_run"`
-
-	Context          types.String `tfsdk:"context"`
-	Dockerfile       types.String `tfsdk:"dockerfile"`
-	Destination      types.String `tfsdk:"destination"`
-	BuildArg         types.Map    `tfsdk:"build_arg"`
-	RegistryUsername types.String `tfsdk:"registry_username"`
-	RegistryPassword types.String `tfsdk:"registry_password"`
-	Cache            types.Bool   `tfsdk:"cache"`
-	NoPush           types.Bool   `tfsdk:"no_push"`
-	PushRetry        types.Int64  `tfsdk:"push_retry"`
-	Reproducible     types.Bool   `tfsdk:"reproducible"`
-	Verbosity        types.String `tfsdk:"verbosity"`
+	BuildID         types.String `tfsdk:"build_id"`
+	Digest          types.String `tfsdk:"digest"`
+	PlatformDigests types.Map    `tfsdk:"platform_digests"`
+	GitUsername     types.String `tfsdk:"git_username"`
+	GitPassword     types.String `tfsdk:"git_password"`
+	AlwaysRun       types.Bool   `tfsdk:"always_run"`
+	Triggers        types.Map    `tfsdk:"triggers"`
+
+	Context          types.String      `tfsdk:"context"`
+	Dockerfile       types.String      `tfsdk:"dockerfile"`
+	Destination      types.String      `tfsdk:"destination"`
+	BuildArg         types.Map         `tfsdk:"build_arg"`
+	RegistryUsername types.String      `tfsdk:"registry_username"`
+	RegistryPassword types.String      `tfsdk:"registry_password"`
+	Cache            *imageCacheModel  `tfsdk:"cache"`
+	CacheVolume      *cacheVolumeModel `tfsdk:"cache_volume"`
+	NoPush           types.Bool        `tfsdk:"no_push"`
+	PushRetry        types.Int64       `tfsdk:"push_retry"`
+	Reproducible     types.Bool        `tfsdk:"reproducible"`
+	Verbosity        types.String      `tfsdk:"verbosity"`
+	SkipIfExists     types.Bool        `tfsdk:"skip_if_exists"`
+	Target           types.String      `tfsdk:"target"`
+	SkipUnusedStages types.Bool        `tfsdk:"skip_unused_stages"`
+	Platforms        types.List        `tfsdk:"platforms"`
+	Labels           types.Map         `tfsdk:"labels"`
+	OCILabels        types.Bool        `tfsdk:"oci_labels"`
+
+	InsecureRegistries   types.List `tfsdk:"insecure_registries"`
+	SkipTLSVerify        types.Bool `tfsdk:"skip_tls_verify"`
+	SkipTLSVerifyPull    types.Bool `tfsdk:"skip_tls_verify_pull"`
+	RegistryCertificates types.List `tfsdk:"registry_certificates"`
+	RegistryMirrors      types.List `tfsdk:"registry_mirrors"`
+	RegistryMap          types.Map  `tfsdk:"registry_map"`
+
+	HTTPProxy  types.String `tfsdk:"http_proxy"`
+	HTTPSProxy types.String `tfsdk:"https_proxy"`
+	NoProxy    types.String `tfsdk:"no_proxy"`
+
+	ServiceAccountName           types.String `tfsdk:"service_account_name"`
+	AutomountServiceAccountToken types.Bool   `tfsdk:"automount_service_account_token"`
+	PodAnnotations               types.Map    `tfsdk:"pod_annotations"`
+	PodLabels                    types.Map    `tfsdk:"pod_labels"`
+	KubernetesAnnotations        types.Map    `tfsdk:"kubernetes_annotations"`
+	KubernetesLabels             types.Map    `tfsdk:"kubernetes_labels"`
+	KeepFailedBuilds             types.Bool   `tfsdk:"keep_failed_builds"`
+}
+
+type imageCacheModel struct {
+	Enabled    types.Bool   `tfsdk:"enabled"`
+	Repo       types.String `tfsdk:"repo"`
+	TTL        types.String `tfsdk:"ttl"`
+	CopyLayers types.Bool   `tfsdk:"copy_layers"`
+	RunLayers  types.Bool   `tfsdk:"run_layers"`
+	Compressed types.Bool   `tfsdk:"compressed"`
+	Username   types.String `tfsdk:"username"`
+	Password   types.String `tfsdk:"password"`
+}
+
+type cacheVolumeModel struct {
+	ClaimName    types.String `tfsdk:"claim_name"`
+	Size         types.String `tfsdk:"size"`
+	StorageClass types.String `tfsdk:"storage_class"`
+	AccessMode   types.String `tfsdk:"access_mode"`
 }
 
 // NewImageResource is a helper function to simplify the provider implementation.
@@ -47,7 +107,7 @@ func NewImageResource() resource.Resource {
 
 // imageResource is the resource implementation.
 type imageResource struct {
-	restConfig *rest.Config
+	providerData *providerData
 }
 
 // Metadata returns the resource type name.
@@ -59,11 +119,115 @@ func (r *imageResource) Metadata(_ context.Context, req resource.MetadataRequest
 func (r *imageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: `Specify the image to build.`,
+		Version:     1,
+		Blocks: map[string]schema.Block{
+			"cache": schema.SingleNestedBlock{
+				Description: "Layer cache of the build.",
+				Attributes: map[string]schema.Attribute{
+					"enabled": schema.BoolAttribute{
+						Optional:    true,
+						Description: "Set to true to opt in caching",
+					},
+					"repo": schema.StringAttribute{
+						Optional: true,
+						Description: "Remote repository to store the cached layers. " +
+							"(default destination repository with \"/cache\" suffix)",
+					},
+					"ttl": schema.StringAttribute{
+						Optional:    true,
+						Description: "Cache timeout, e.g. \"6h\". (default \"336h\")",
+					},
+					"copy_layers": schema.BoolAttribute{
+						Optional:    true,
+						Description: "Set to true to cache the layers of COPY instructions",
+					},
+					"run_layers": schema.BoolAttribute{
+						Optional:    true,
+						Description: "Set to false to not cache the layers of RUN instructions. (default true)",
+					},
+					"compressed": schema.BoolAttribute{
+						Optional: true,
+						Description: "Set to false to not compress the cached layers, " +
+							"using more disk but less memory. (default true)",
+					},
+					"username": schema.StringAttribute{
+						Optional:    true,
+						Sensitive:   true,
+						Description: "Username for the cache repository, if it lives in another registry",
+					},
+					"password": schema.StringAttribute{
+						Optional:    true,
+						Sensitive:   true,
+						Description: "Password for the cache repository, if it lives in another registry",
+					},
+				},
+			},
+			"registry_certificates": schema.ListNestedBlock{
+				Description: "CA certificates to verify the registries with, " +
+					"merged with the provider defaults by host.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"host": schema.StringAttribute{
+							Required:    true,
+							Description: "Host of the registry, e.g. \"harbor.local\"",
+						},
+						"ca_pem": schema.StringAttribute{
+							Required:    true,
+							Description: "CA certificate bundle in PEM",
+						},
+					},
+				},
+			},
+			"cache_volume": schema.SingleNestedBlock{
+				Description: "Persistent volume of the base image cache, e.g. populated by kaniko_cache_warmer, " +
+					"it is mounted read-only as the cache directory of the build whether the cache is enabled or not.",
+				Attributes: map[string]schema.Attribute{
+					"claim_name": schema.StringAttribute{
+						Optional: true,
+						Description: "Name of the persistent volume claim to mount, " +
+							"it must exist if size is not set. (default \"kaniko-cache\")",
+					},
+					"size": schema.StringAttribute{
+						Optional: true,
+						Description: "Size to create the persistent volume claim with if it does not exist, " +
+							"e.g. \"10Gi\"",
+					},
+					"storage_class": schema.StringAttribute{
+						Optional:    true,
+						Description: "Storage class to create the persistent volume claim with",
+					},
+					"access_mode": schema.StringAttribute{
+						Optional: true,
+						Description: "Access mode to create the persistent volume claim with, " +
+							"ReadWriteOnce only allows the builds and the warmer on the same node " +
+							"(default \"ReadWriteMany\")",
+						Validators: []validator.String{
+							OneOfValidator(cacheClaimAccessModes...),
+						},
+					},
+				},
+			},
+		},
 		Attributes: map[string]schema.Attribute{
 			"build_id": schema.StringAttribute{
 				Computed: true,
 				PlanModifiers: []planmodifier.String{
-					BuildIDModifier(),
+					BuildOutputModifier(),
+				},
+			},
+			"digest": schema.StringAttribute{
+				Computed:    true,
+				Description: "Digest of the built image, or of the image index while building for platforms.",
+				PlanModifiers: []planmodifier.String{
+					BuildOutputModifier(),
+				},
+			},
+			"platform_digests": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Digests of the built images by platform while building for platforms.",
+				PlanModifiers: []planmodifier.Map{
+					BuildOutputMapModifier(),
 				},
 			},
 			"git_username": schema.StringAttribute{
@@ -80,6 +244,11 @@ func (r *imageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Optional:    true,
 				Description: "Set to true to run build image every time even variables aren't change",
 			},
+			"triggers": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Arbitrary values that rebuild the image when changed, e.g. a git commit or a file hash",
+			},
 			"registry_username": schema.StringAttribute{
 				Optional:    true,
 				Sensitive:   true,
@@ -93,10 +262,19 @@ func (r *imageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			"context": schema.StringAttribute{
 				Required:    true,
 				Description: "Location of the build context",
+				Validators: []validator.String{
+					BuildContextValidator(),
+				},
 			},
 			"destination": schema.StringAttribute{
 				Required:    true,
-				Description: "Image name to be built and pushed.",
+				Description: "Image tag to be built and pushed, e.g. registry/repository:tag.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					ImageTagValidator(),
+				},
 			},
 			"dockerfile": schema.StringAttribute{
 				Optional:    true,
@@ -106,10 +284,9 @@ func (r *imageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				ElementType: types.StringType,
 				Optional:    true,
 				Description: "Arguments at build time.",
-			},
-			"cache": schema.BoolAttribute{
-				Optional:    true,
-				Description: "Set to true to opt in caching",
+				Validators: []validator.Map{
+					BuildArgKeysValidator(),
+				},
 			},
 			"no_push": schema.BoolAttribute{
 				Optional:    true,
@@ -118,6 +295,9 @@ func (r *imageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			"push_retry": schema.Int64Attribute{
 				Optional:    true,
 				Description: "Number of retries for the push operation",
+				Validators: []validator.Int64{
+					AtLeastValidator(0),
+				},
 			},
 			"reproducible": schema.BoolAttribute{
 				Optional:    true,
@@ -126,11 +306,209 @@ func (r *imageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			"verbosity": schema.StringAttribute{
 				Optional:    true,
 				Description: "Log level (trace, debug, info, warn, error, fatal, panic) (default info)",
+				Validators: []validator.String{
+					OneOfValidator(verbosityLevels...),
+				},
+			},
+			"target": schema.StringAttribute{
+				Optional:    true,
+				Description: "Stage of a multi-stage dockerfile to build",
+			},
+			"skip_unused_stages": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Set to true to skip the stages not needed by the target stage",
+			},
+			"platforms": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Platforms to build for, e.g. \"linux/arm64\", each is built by a job on nodes " +
+					"of the platform and pushed to the destination tag suffixed with it, " +
+					"e.g. \"latest-linux-arm64\", then an image index of them is pushed to the destination, " +
+					"the build fails if no node of a platform is available within 10 minutes",
+				Validators: []validator.List{
+					ValueStringsAreValidator(PlatformValidator()),
+				},
+			},
+			"labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Labels to add to the built image.",
+			},
+			"oci_labels": schema.BoolAttribute{
+				Optional: true,
+				Description: "Set to true to label the built image with org.opencontainers.image.source and " +
+					"org.opencontainers.image.revision of a git context, and org.opencontainers.image.created " +
+					"unless reproducible, the labels set in labels take precedence.",
+			},
+			"insecure_registries": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Registries to access over plain HTTP, overrides the provider default.",
+			},
+			"skip_tls_verify": schema.BoolAttribute{
+				Optional: true,
+				Description: "Set to true to skip verifying the TLS certificates of the registries, " +
+					"overrides the provider default.",
+			},
+			"skip_tls_verify_pull": schema.BoolAttribute{
+				Optional: true,
+				Description: "Set to true to skip verifying the TLS certificates of the registries pulled from, " +
+					"overrides the provider default.",
+			},
+			"registry_mirrors": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Mirrors to pull the images of docker hub from, overrides the provider default.",
+			},
+			"registry_map": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Mirrors to pull the images of other registries from, separated by \";\", " +
+					"merged with the provider defaults by registry.",
+			},
+			"http_proxy": schema.StringAttribute{
+				Optional:    true,
+				Description: "Proxy of the HTTP requests of the build, overrides the provider default.",
+			},
+			"https_proxy": schema.StringAttribute{
+				Optional:    true,
+				Description: "Proxy of the HTTPS requests of the build, overrides the provider default.",
+			},
+			"no_proxy": schema.StringAttribute{
+				Optional:    true,
+				Description: "Hosts not to access through the proxies, overrides the provider default.",
+			},
+			"skip_if_exists": schema.BoolAttribute{
+				Optional: true,
+				Description: "Set to true to skip building if the destination exists and was built " +
+					"with the same inputs and triggers, it is ignored while always_run is set",
+			},
+			"service_account_name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Service account to run the build pod as, overrides the provider default.",
+			},
+			"automount_service_account_token": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether to mount the service account token, overrides the provider default.",
+			},
+			"pod_annotations": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Annotations to add to the build pod, merged with the provider defaults.",
+			},
+			"pod_labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Labels to add to the build pod, merged with the provider defaults.",
+			},
+			"kubernetes_annotations": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Annotations to add to the kubernetes objects created for the build, " +
+					"merged with the provider defaults.",
+			},
+			"kubernetes_labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Labels to add to the kubernetes objects created for the build, " +
+					"merged with the provider defaults.",
+			},
+			"keep_failed_builds": schema.BoolAttribute{
+				Optional: true,
+				Description: "Set to true to keep the job of a failed build for debugging until its TTL expires, " +
+					"overrides the provider default.",
 			},
 		},
 	}
 }
 
+// ValidateConfig rejects the conflicting attribute combinations.
+func (r *imageResource) ValidateConfig(
+	ctx context.Context,
+	req resource.ValidateConfigRequest,
+	resp *resource.ValidateConfigResponse,
+) {
+	var config imageResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Cache != nil && !config.Cache.TTL.IsNull() && !config.Cache.TTL.IsUnknown() {
+		if _, err := time.ParseDuration(config.Cache.TTL.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cache").AtName("ttl"), "invalid duration", err.Error())
+		}
+	}
+
+	if config.CacheVolume != nil {
+		if config.CacheVolume.ClaimName.IsNull() && config.CacheVolume.Size.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("cache_volume"), "missing cache volume",
+				"claim_name or size must be set in cache_volume.")
+		}
+		if !config.CacheVolume.Size.IsNull() && !config.CacheVolume.Size.IsUnknown() {
+			if _, err := k8sresource.ParseQuantity(config.CacheVolume.Size.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("cache_volume").AtName("size"), "invalid size",
+					err.Error())
+			}
+		}
+		if !config.CacheVolume.StorageClass.IsNull() && config.CacheVolume.Size.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("cache_volume").AtName("storage_class"),
+				"conflicting attributes", "storage_class is only used while size is set.")
+		}
+		if !config.CacheVolume.AccessMode.IsNull() && config.CacheVolume.Size.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("cache_volume").AtName("access_mode"),
+				"conflicting attributes", "access_mode is only used while size is set.")
+		}
+	}
+
+	if !config.Platforms.IsNull() && config.NoPush.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("no_push"), "conflicting attributes",
+			"the images must be pushed to build an image index for platforms.")
+	}
+
+	if config.AlwaysRun.ValueBool() && !config.Triggers.IsNull() {
+		resp.Diagnostics.AddAttributeWarning(path.Root("triggers"), "redundant attributes",
+			"triggers have no effect while always_run is true.")
+	}
+}
+
+// UpgradeState upgrades the prior state to the current schema version.
+func (r *imageResource) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {StateUpgrader: upgradeImageStateV0},
+	}
+}
+
+// upgradeImageStateV0 upgrades the state of version 0,
+// which has cache as a bool attribute instead of a block.
+func upgradeImageStateV0(_ context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(req.RawState.JSON, &state); err != nil {
+		resp.Diagnostics.AddError("failed to upgrade state", err.Error())
+		return
+	}
+
+	var cache bool
+	if raw, ok := state["cache"]; ok {
+		if err := json.Unmarshal(raw, &cache); err != nil {
+			resp.Diagnostics.AddError("failed to upgrade state", err.Error())
+			return
+		}
+	}
+	state["cache"] = json.RawMessage("null")
+	if cache {
+		state["cache"] = json.RawMessage(`{"enabled":true}`)
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to upgrade state", err.Error())
+		return
+	}
+	resp.DynamicValue = &tfprotov6.DynamicValue{JSON: b}
+}
+
 // Create creates the resource and sets the initial Terraform state.
 func (r *imageResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	tflog.Info(ctx, "Start Create")
@@ -184,6 +562,24 @@ func (r *imageResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	// An imported image is not built again, its inputs are only recorded with their build id.
+	run, diags := buildWillRun(ctx, req.Plan, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !run {
+		options, _, err := r.runOptions(ctx, plan)
+		if err != nil {
+			resp.Diagnostics.AddError("invalid build options", err.Error())
+			return
+		}
+		plan.BuildID = types.StringValue(options.ID)
+		diags = resp.State.Set(ctx, &plan)
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
 	state, err := r.build(ctx, plan)
 	if err != nil {
 		resp.Diagnostics.AddError("kaniko build failed", err.Error())
@@ -202,6 +598,68 @@ func (r *imageResource) Update(ctx context.Context, req resource.UpdateRequest,
 func (r *imageResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 }
 
+// ImportState imports an existing image by its destination tag reference, e.g. registry/repository:tag,
+// or by its digest reference, e.g. registry/repository@sha256:digest, which must be pointed to by a single tag
+// of the repository as the destination kaniko pushes to is a tag.
+func (r *imageResource) ImportState(
+	ctx context.Context,
+	req resource.ImportStateRequest,
+	resp *resource.ImportStateResponse,
+) {
+	registryUsername, registryPassword := registryCredentials(types.StringNull(), types.StringNull())
+	registryOpts := r.providerData.registryOptions().withAuth(registryUsername, registryPassword, req.ID)
+	destination := req.ID
+	if ref, err := registryOpts.parseReference(req.ID); err == nil {
+		if d, ok := ref.(name.Digest); ok {
+			tags, err := getRepositoryTags(ctx, registryOpts, d.Context().String(), d.DigestStr())
+			if err != nil {
+				resp.Diagnostics.AddError("failed to list image tags", err.Error())
+				return
+			}
+			switch len(tags) {
+			case 0:
+				resp.Diagnostics.AddError("invalid import id",
+					fmt.Sprintf("no tag of %s points to %s, import the image by its destination tag, "+
+						"e.g. registry/repository:tag.", d.Context(), d.DigestStr()))
+				return
+			case 1:
+				destination = tags[0]
+			default:
+				resp.Diagnostics.AddError("invalid import id",
+					fmt.Sprintf("%s is pointed to by the tags %s, import the image by its destination tag.",
+						req.ID, strings.Join(tags, ", ")))
+				return
+			}
+		}
+	}
+	if _, err := registryOpts.parseTag(destination); err != nil {
+		resp.Diagnostics.AddError("invalid import id",
+			fmt.Sprintf("%q is not an image reference, import the image by its destination, "+
+				"e.g. registry/repository:tag.", req.ID))
+		return
+	}
+
+	desc, err := getDescriptor(ctx, registryOpts, destination)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to resolve image", err.Error())
+		return
+	}
+
+	platformDigests := types.MapNull(types.StringType)
+	if desc.MediaType.IsIndex() {
+		digests, err := getPlatformDigests(ctx, registryOpts, destination)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to resolve image index", err.Error())
+			return
+		}
+		platformDigests = stringMapValue(digests)
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("destination"), destination)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("digest"), desc.Digest.String())...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("platform_digests"), platformDigests)...)
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *imageResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -209,20 +667,24 @@ func (r *imageResource) Configure(_ context.Context, req resource.ConfigureReque
 	}
 
 	var ok bool
-	r.restConfig, ok = req.ProviderData.(*rest.Config)
+	r.providerData, ok = req.ProviderData.(*providerData)
 	if !ok {
-		resp.Diagnostics.AddError("invalid provider data", "expected a rest config")
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
 	}
 }
 
-func (r *imageResource) build(ctx context.Context, plan imageResourceModel) (*imageResourceModel, error) {
+// runOptions returns the options to run the build of the plan with,
+// and the options for the provider to access the registries of the build.
+func (r *imageResource) runOptions(
+	ctx context.Context,
+	plan imageResourceModel,
+) (*runOptions, registryOptions, error) {
 	// Default values to environment variables, but override
 	// with Terraform configuration value if set.
 
 	gitUsername := os.Getenv("GIT_USERNAME")
 	gitPassword := os.Getenv("GIT_PASSWORD")
-	registryUsername := os.Getenv("REGISTRY_USERNAME")
-	registryPassword := os.Getenv("REGISTRY_PASSWORD")
+	registryUsername, registryPassword := registryCredentials(plan.RegistryUsername, plan.RegistryPassword)
 	var pushRetry int64 = 5
 	verbosity := "debug"
 
@@ -234,14 +696,6 @@ func (r *imageResource) build(ctx context.Context, plan imageResourceModel) (*im
 		gitPassword = plan.GitPassword.ValueString()
 	}
 
-	if !plan.RegistryUsername.IsNull() {
-		registryUsername = plan.RegistryUsername.ValueString()
-	}
-
-	if !plan.RegistryPassword.IsNull() {
-		registryPassword = plan.RegistryPassword.ValueString()
-	}
-
 	if !plan.PushRetry.IsNull() {
 		pushRetry = plan.PushRetry.ValueInt64()
 	}
@@ -250,28 +704,256 @@ func (r *imageResource) build(ctx context.Context, plan imageResourceModel) (*im
 		verbosity = plan.Verbosity.ValueString()
 	}
 
-	buildID := fmt.Sprintf("kaniko-%s", utils.String(8))
+	serviceAccountName := r.providerData.ServiceAccountName
+	if !plan.ServiceAccountName.IsNull() {
+		serviceAccountName = plan.ServiceAccountName.ValueString()
+	}
+
+	keepFailedBuilds := r.providerData.KeepFailedBuilds
+	if !plan.KeepFailedBuilds.IsNull() {
+		keepFailedBuilds = plan.KeepFailedBuilds.ValueBool()
+	}
+
+	automountServiceAccountToken := r.providerData.AutomountServiceAccountToken
+	if !plan.AutomountServiceAccountToken.IsNull() {
+		automountServiceAccountToken = pointer.Bool(plan.AutomountServiceAccountToken.ValueBool())
+	}
+
+	insecureRegistries := r.providerData.InsecureRegistries
+	if !plan.InsecureRegistries.IsNull() {
+		insecureRegistries = stringList(plan.InsecureRegistries)
+	}
+
+	skipTLSVerify := r.providerData.SkipTLSVerify
+	if !plan.SkipTLSVerify.IsNull() {
+		skipTLSVerify = plan.SkipTLSVerify.ValueBool()
+	}
+
+	skipTLSVerifyPull := r.providerData.SkipTLSVerifyPull
+	if !plan.SkipTLSVerifyPull.IsNull() {
+		skipTLSVerifyPull = plan.SkipTLSVerifyPull.ValueBool()
+	}
+
+	registryMirrors := r.providerData.RegistryMirrors
+	if !plan.RegistryMirrors.IsNull() {
+		registryMirrors = stringList(plan.RegistryMirrors)
+	}
+
+	proxy := r.providerData.Proxy
+	if !plan.HTTPProxy.IsNull() {
+		proxy.HTTPProxy = plan.HTTPProxy.ValueString()
+	}
+	if !plan.HTTPSProxy.IsNull() {
+		proxy.HTTPSProxy = plan.HTTPSProxy.ValueString()
+	}
+	if !plan.NoProxy.IsNull() {
+		proxy.NoProxy = plan.NoProxy.ValueString()
+	}
+
+	registryCertificates := mergeRegistryCertificates(r.providerData.RegistryCertificates, plan.RegistryCertificates)
+
 	options := &runOptions{
-		ID:               buildID,
 		GitPassword:      gitPassword,
 		GitUsername:      gitUsername,
 		RegistryUsername: registryUsername,
 		RegistryPassword: registryPassword,
+		RegistryAuths:    r.providerData.RegistryAuths,
 		Context:          plan.Context.ValueString(),
 		Dockerfile:       plan.Dockerfile.ValueString(),
 		Destination:      plan.Destination.ValueString(),
-		Cache:            plan.Cache.ValueBool(),
+		BuildArg:         mergeStringMap(nil, plan.BuildArg),
 		NoPush:           plan.NoPush.ValueBool(),
 		PushRetry:        pushRetry,
 		Reproducible:     plan.Reproducible.ValueBool(),
+		Target:           plan.Target.ValueString(),
+		SkipUnusedStages: plan.SkipUnusedStages.ValueBool(),
+		ImageLabels:      mergeStringMap(nil, plan.Labels),
+		OCILabels:        plan.OCILabels.ValueBool(),
+		Triggers:         mergeStringMap(nil, plan.Triggers),
 		Verbosity:        verbosity,
+		KeepFailedBuilds: keepFailedBuilds,
+
+		InsecureRegistries:   insecureRegistries,
+		SkipTLSVerify:        skipTLSVerify,
+		SkipTLSVerifyPull:    skipTLSVerifyPull,
+		RegistryCertificates: registryCertificates,
+		RegistryMirrors:      registryMirrors,
+		RegistryMap:          mergeStringMap(r.providerData.RegistryMap, plan.RegistryMap),
+		Proxy:                proxy,
+
+		ServiceAccountName:           serviceAccountName,
+		AutomountServiceAccountToken: automountServiceAccountToken,
+		PodAnnotations:               mergeStringMap(r.providerData.PodAnnotations, plan.PodAnnotations),
+		PodLabels:                    mergeStringMap(r.providerData.PodLabels, plan.PodLabels),
+		Annotations:                  mergeStringMap(r.providerData.KubernetesAnnotations, plan.KubernetesAnnotations),
+		Labels:                       mergeStringMap(r.providerData.KubernetesLabels, plan.KubernetesLabels),
+	}
+
+	if plan.Cache != nil && plan.Cache.Enabled.ValueBool() {
+		options.Cache = true
+		options.CacheRepo = plan.Cache.Repo.ValueString()
+		options.CacheTTL = plan.Cache.TTL.ValueString()
+		options.CacheCopyLayers = plan.Cache.CopyLayers.ValueBool()
+		options.CacheRepoUsername = registryUsername
+		options.CacheRepoPassword = registryPassword
+		if !plan.Cache.RunLayers.IsNull() {
+			options.CacheRunLayers = pointer.Bool(plan.Cache.RunLayers.ValueBool())
+		}
+		if !plan.Cache.Compressed.IsNull() {
+			options.CompressedCache = pointer.Bool(plan.Cache.Compressed.ValueBool())
+		}
+		if !plan.Cache.Username.IsNull() {
+			options.CacheRepoUsername = plan.Cache.Username.ValueString()
+		}
+		if !plan.Cache.Password.IsNull() {
+			options.CacheRepoPassword = plan.Cache.Password.ValueString()
+		}
 	}
 
-	err := kanikoBuild(ctx, r.restConfig, options)
+	if plan.CacheVolume != nil {
+		options.CacheClaimName = cacheClaimName
+		if !plan.CacheVolume.ClaimName.IsNull() {
+			options.CacheClaimName = plan.CacheVolume.ClaimName.ValueString()
+		}
+		options.CacheClaimSize = plan.CacheVolume.Size.ValueString()
+		options.CacheClaimStorageClass = plan.CacheVolume.StorageClass.ValueString()
+		options.CacheClaimAccessMode = plan.CacheVolume.AccessMode.ValueString()
+	}
+
+	if !plan.Platforms.IsNull() {
+		if diags := plan.Platforms.ElementsAs(ctx, &options.Platforms, false); diags.HasError() {
+			return nil, registryOptions{}, fmt.Errorf("invalid platforms: %v", diags)
+		}
+	}
+
+	options.ID = options.buildID()
+
+	registryOpts := r.providerData.registryOptions().withAuth(registryUsername, registryPassword, options.Destination)
+	registryOpts.InsecureRegistries = insecureRegistries
+	registryOpts.SkipTLSVerify = skipTLSVerify
+	registryOpts.RegistryCertificates = registryCertificates
+	registryOpts.Proxy = proxy
+
+	return options, registryOpts, nil
+}
+
+func (r *imageResource) build(
+	ctx context.Context,
+	plan imageResourceModel,
+) (*imageResourceModel, error) {
+	options, registryOpts, err := r.runOptions(ctx, plan)
 	if err != nil {
 		return nil, err
 	}
 
-	plan.BuildID = types.StringValue(buildID)
+	// The existing image never satisfies a build to run every time.
+	if plan.SkipIfExists.ValueBool() && !plan.AlwaysRun.ValueBool() {
+		var platform string
+		if len(options.Platforms) != 0 {
+			platform = options.Platforms[0]
+		}
+		digest, err := getBuiltDigest(ctx, registryOpts, options.Destination, platform, options.inputHash())
+		if err != nil {
+			tflog.Warn(ctx, "failed to check the existing image, building it", map[string]any{"error": err})
+		}
+		if digest != "" {
+			tflog.Info(ctx, "image built with the same inputs exists, skip building", map[string]any{"digest": digest})
+			plan.BuildID = types.StringValue(options.ID)
+			plan.Digest = types.StringValue(digest)
+			plan.PlatformDigests = types.MapNull(types.StringType)
+			if len(options.Platforms) != 0 {
+				digests, err := getPlatformDigests(ctx, registryOpts, options.Destination)
+				if err != nil {
+					return nil, err
+				}
+				plan.PlatformDigests = stringMapValue(digests)
+			}
+			return &plan, nil
+		}
+	}
+
+	if len(options.Platforms) != 0 {
+		return r.buildPlatforms(ctx, plan, options, registryOpts)
+	}
+
+	result, err := kanikoBuild(ctx, r.providerData.RestConfig, options)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.BuildID = types.StringValue(result.ID)
+	plan.Digest = types.StringNull()
+	plan.PlatformDigests = types.MapNull(types.StringType)
+	if result.Digest != "" {
+		plan.Digest = types.StringValue(result.Digest)
+	}
+	return &plan, nil
+}
+
+// buildPlatforms runs a build of each platform concurrently,
+// then pushes the image index of the built images to the destination.
+func (r *imageResource) buildPlatforms(
+	ctx context.Context,
+	plan imageResourceModel,
+	options *runOptions,
+	registryOpts registryOptions,
+) (*imageResourceModel, error) {
+	results := make([]*runResult, len(options.Platforms))
+	errs := make([]error, len(options.Platforms))
+
+	var wg sync.WaitGroup
+	for i, platform := range options.Platforms {
+		opts := *options
+		opts.Platform = platform
+		opts.ID = opts.buildID()
+
+		wg.Add(1)
+		go func(i int, opts *runOptions) {
+			defer wg.Done()
+			results[i], errs[i] = kanikoBuild(ctx, r.providerData.RestConfig, opts)
+		}(i, &opts)
+	}
+	wg.Wait()
+
+	var messages []string
+	for i, err := range errs {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %v", options.Platforms[i], err))
+		}
+	}
+	if len(messages) != 0 {
+		return nil, errors.New(strings.Join(messages, "\n"))
+	}
+
+	manifests := make([]indexManifest, 0, len(results))
+	digests := make(map[string]string, len(results))
+	for i, result := range results {
+		opts := *options
+		opts.Platform = options.Platforms[i]
+		ref := opts.pushDestination()
+		if result.Digest != "" {
+			tag, err := name.ParseReference(ref)
+			if err != nil {
+				return nil, err
+			}
+			ref = tag.Context().Digest(result.Digest).String()
+		}
+
+		platform, err := parsePlatform(opts.Platform)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, indexManifest{Reference: ref, Platform: platform})
+		digests[opts.Platform] = result.Digest
+	}
+
+	digest, err := pushIndex(ctx, registryOpts, options.Destination, manifests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to push the image index: %w", err)
+	}
+
+	plan.BuildID = types.StringValue(options.ID)
+	plan.Digest = types.StringValue(digest)
+	plan.PlatformDigests = stringMapValue(digests)
 	return &plan, nil
 }
@@ -0,0 +1,277 @@
+package kaniko
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &imageIndexResource{}
+	_ resource.ResourceWithConfigure      = &imageIndexResource{}
+	_ resource.ResourceWithValidateConfig = &imageIndexResource{}
+)
+
+type imageIndexResourceModel struct {
+	Digest           types.String `tfsdk:"digest"`
+	Destination      types.String `tfsdk:"destination"`
+	Manifests        types.List   `tfsdk:"manifest"`
+	RegistryUsername types.String `tfsdk:"registry_username"`
+	RegistryPassword types.String `tfsdk:"registry_password"`
+}
+
+type imageIndexManifestModel struct {
+	Reference types.String `tfsdk:"reference"`
+	Platform  types.String `tfsdk:"platform"`
+}
+
+// NewImageIndexResource is a helper function to simplify the provider implementation.
+func NewImageIndexResource() resource.Resource {
+	return &imageIndexResource{}
+}
+
+// imageIndexResource is the resource implementation.
+type imageIndexResource struct {
+	providerData *providerData
+}
+
+// Metadata returns the resource type name.
+func (r *imageIndexResource) Metadata(
+	_ context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_image_index"
+}
+
+// Schema defines the schema for the resource.
+func (r *imageIndexResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Combine existing images into an image index, e.g. images of different platforms.`,
+		Blocks: map[string]schema.Block{
+			"manifest": schema.ListNestedBlock{
+				Description: "Image to add to the index.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"reference": schema.StringAttribute{
+							Required:    true,
+							Description: "Reference of the image, e.g. registry/repository@sha256:digest",
+							Validators: []validator.String{
+								ImageReferenceValidator(),
+							},
+						},
+						"platform": schema.StringAttribute{
+							Optional: true,
+							Description: "Platform of the image, e.g. \"linux/arm64\". " +
+								"(default read from the image config)",
+							Validators: []validator.String{
+								PlatformValidator(),
+							},
+						},
+					},
+				},
+			},
+		},
+		Attributes: map[string]schema.Attribute{
+			"digest": schema.StringAttribute{
+				Computed:    true,
+				Description: "Digest of the pushed image index.",
+			},
+			"destination": schema.StringAttribute{
+				Required:    true,
+				Description: "Image tag of the index to be pushed, e.g. registry/repository:tag.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					ImageTagValidator(),
+				},
+			},
+			"registry_username": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Username for the image registry",
+			},
+			"registry_password": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password for the image registry",
+			},
+		},
+	}
+}
+
+// ValidateConfig rejects the conflicting attribute combinations.
+func (r *imageIndexResource) ValidateConfig(
+	ctx context.Context,
+	req resource.ValidateConfigRequest,
+	resp *resource.ValidateConfigResponse,
+) {
+	var config imageIndexResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The manifests are unknown while generated by a dynamic block of unknown values.
+	if !config.Manifests.IsUnknown() && len(config.Manifests.Elements()) == 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "missing manifests",
+			"at least one manifest must be set.")
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *imageIndexResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Info(ctx, "Start Create")
+
+	// Retrieve values from plan.
+	var plan imageIndexResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.push(ctx, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to push image index", err.Error())
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Read refreshes the Terraform state with the latest data,
+// the resource is removed to push the index again if the destination is missing or points to another digest.
+func (r *imageIndexResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state imageIndexResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	desc, err := getDescriptor(ctx, r.registryOptions(state), state.Destination.ValueString())
+	if err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			resp.Diagnostics.AddError("failed to resolve image index", err.Error())
+			return
+		}
+		tflog.Warn(ctx, "image index not found, pushing it again", map[string]any{
+			"destination": state.Destination.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if desc.Digest.String() != state.Digest.ValueString() {
+		tflog.Warn(ctx, "image index changed, pushing it again", map[string]any{
+			"destination": state.Destination.ValueString(),
+			"digest":      desc.Digest.String(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *imageIndexResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Info(ctx, "Start Update")
+
+	// Retrieve values from plan.
+	var plan imageIndexResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.push(ctx, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to push image index", err.Error())
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success,
+// the pushed index is left in the registry.
+func (r *imageIndexResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *imageIndexResource) Configure(
+	_ context.Context,
+	req resource.ConfigureRequest,
+	resp *resource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	var ok bool
+	r.providerData, ok = req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
+	}
+}
+
+// registryOptions returns the registry options of the provider with the resource credentials for the destination.
+func (r *imageIndexResource) registryOptions(m imageIndexResourceModel) registryOptions {
+	username, password := registryCredentials(m.RegistryUsername, m.RegistryPassword)
+	return r.providerData.registryOptions().withAuth(username, password, m.Destination.ValueString())
+}
+
+func (r *imageIndexResource) push(
+	ctx context.Context,
+	plan imageIndexResourceModel,
+) (*imageIndexResourceModel, error) {
+	var models []imageIndexManifestModel
+	if diags := plan.Manifests.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, fmt.Errorf("invalid manifests: %v", diags)
+	}
+
+	manifests := make([]indexManifest, 0, len(models))
+	for _, m := range models {
+		manifest := indexManifest{Reference: m.Reference.ValueString()}
+		if !m.Platform.IsNull() {
+			platform, err := parsePlatform(m.Platform.ValueString())
+			if err != nil {
+				return nil, err
+			}
+			manifest.Platform = platform
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	digest, err := pushIndex(ctx, r.registryOptions(plan), plan.Destination.ValueString(), manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Digest = types.StringValue(digest)
+	return &plan, nil
+}
@@ -0,0 +1,326 @@
+package kaniko
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource              = &imageTagResource{}
+	_ resource.ResourceWithConfigure = &imageTagResource{}
+)
+
+type imageTagResourceModel struct {
+	Digest           types.String `tfsdk:"digest"`
+	Source           types.String `tfsdk:"source"`
+	Destinations     types.List   `tfsdk:"destinations"`
+	RegistryUsername types.String `tfsdk:"registry_username"`
+	RegistryPassword types.String `tfsdk:"registry_password"`
+}
+
+// NewImageTagResource is a helper function to simplify the provider implementation.
+func NewImageTagResource() resource.Resource {
+	return &imageTagResource{}
+}
+
+// imageTagResource is the resource implementation.
+type imageTagResource struct {
+	providerData *providerData
+}
+
+// Metadata returns the resource type name.
+func (r *imageTagResource) Metadata(
+	_ context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_image_tag"
+}
+
+// Schema defines the schema for the resource.
+func (r *imageTagResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Copy an image to other tags without rebuilding it, possibly across registries.`,
+		Attributes: map[string]schema.Attribute{
+			"digest": schema.StringAttribute{
+				Computed:    true,
+				Description: "Digest of the copied image.",
+			},
+			"source": schema.StringAttribute{
+				Required:    true,
+				Description: "Reference of the image to copy, it is resolved to a digest while copying.",
+				Validators: []validator.String{
+					ImageReferenceValidator(),
+				},
+			},
+			"destinations": schema.ListAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Description: "Tags to copy the image to, e.g. registry/repository:tag, they are deleted on destroy " +
+					"if still pointing to the copied image, unless the image is still referenced by the source " +
+					"or by another tag of the repository, as some registries delete the image with its tag",
+				Validators: []validator.List{
+					ValueStringsAreValidator(ImageTagValidator()),
+				},
+			},
+			"registry_username": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Username for the registries of the destinations",
+			},
+			"registry_password": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password for the registries of the destinations",
+			},
+		},
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *imageTagResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Info(ctx, "Start Create")
+
+	// Retrieve values from plan.
+	var plan imageTagResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.copy(ctx, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to copy image", err.Error())
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Read refreshes the Terraform state with the latest data,
+// the destinations missing or pointing to another digest are removed to copy the image again.
+func (r *imageTagResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state imageTagResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	registryOpts := r.registryOptions(state)
+	var destinations []attr.Value
+	for _, d := range stringList(state.Destinations) {
+		digest, err := getTagDigest(ctx, registryOpts, d)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to resolve image tag", err.Error())
+			return
+		}
+		if digest != state.Digest.ValueString() {
+			tflog.Warn(ctx, "image tag changed, copying it again", map[string]any{
+				"destination": d,
+				"digest":      digest,
+			})
+			continue
+		}
+		destinations = append(destinations, types.StringValue(d))
+	}
+	if len(destinations) == 0 {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	state.Destinations = types.ListValueMust(types.StringType, destinations)
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *imageTagResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Info(ctx, "Start Update")
+
+	// Retrieve values from plan.
+	var plan imageTagResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var prior imageTagResourceModel
+	diags = req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.copy(ctx, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to copy image", err.Error())
+		return
+	}
+
+	// Remove the tags no longer wanted.
+	wanted := make(map[string]struct{}, len(plan.Destinations.Elements()))
+	for _, d := range stringList(plan.Destinations) {
+		wanted[d] = struct{}{}
+	}
+	var removed []string
+	for _, d := range stringList(prior.Destinations) {
+		if _, ok := wanted[d]; !ok {
+			removed = append(removed, d)
+		}
+	}
+	resp.Diagnostics.Append(r.untag(ctx, prior, removed)...)
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success,
+// failing to delete the tags only warns as some registries do not support it.
+func (r *imageTagResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Get current state.
+	var state imageTagResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.untag(ctx, state, stringList(state.Destinations))...)
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *imageTagResource) Configure(
+	_ context.Context,
+	req resource.ConfigureRequest,
+	resp *resource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	var ok bool
+	r.providerData, ok = req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("invalid provider data", "expected a provider data")
+	}
+}
+
+// registryOptions returns the registry options of the provider with the resource credentials for the destinations.
+func (r *imageTagResource) registryOptions(m imageTagResourceModel) registryOptions {
+	username, password := registryCredentials(m.RegistryUsername, m.RegistryPassword)
+	return r.providerData.registryOptions().withAuth(username, password, stringList(m.Destinations)...)
+}
+
+func (r *imageTagResource) copy(
+	ctx context.Context,
+	plan imageTagResourceModel,
+) (*imageTagResourceModel, error) {
+	registryOpts := r.registryOptions(plan)
+	desc, err := getDescriptor(ctx, registryOpts, plan.Source.ValueString())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range stringList(plan.Destinations) {
+		tflog.Info(ctx, "copying image", map[string]any{
+			"source":      plan.Source.ValueString(),
+			"digest":      desc.Digest.String(),
+			"destination": d,
+		})
+		if err = copyImage(ctx, registryOpts, desc, d); err != nil {
+			return nil, fmt.Errorf("failed to copy to %s: %w", d, err)
+		}
+	}
+
+	plan.Digest = types.StringValue(desc.Digest.String())
+	return &plan, nil
+}
+
+// untag deletes the given destination tags still pointing to the copied digest,
+// the failures are returned as warnings. Some registries delete the image with its tag,
+// so a tag is kept if the image is still referenced by the source or by another tag of its repository.
+func (r *imageTagResource) untag(
+	ctx context.Context,
+	m imageTagResourceModel,
+	destinations []string,
+) diag.Diagnostics {
+	var diags diag.Diagnostics
+	registryOpts := r.registryOptions(m)
+
+	source, err := registryOpts.parseReference(m.Source.ValueString())
+	if err != nil {
+		diags.AddWarning("failed to delete image tags", err.Error())
+		return diags
+	}
+
+	removed := make(map[string]struct{}, len(destinations))
+	for _, d := range destinations {
+		tag, err := registryOpts.parseTag(d)
+		if err != nil {
+			diags.AddWarning("failed to delete image tag", fmt.Sprintf("%s: %v", d, err))
+			continue
+		}
+		removed[tag.Name()] = struct{}{}
+	}
+
+	for _, d := range destinations {
+		digest, err := getTagDigest(ctx, registryOpts, d)
+		if err != nil {
+			diags.AddWarning("failed to delete image tag", fmt.Sprintf("%s: %v", d, err))
+			continue
+		}
+		if digest != m.Digest.ValueString() {
+			continue
+		}
+
+		tag, err := registryOpts.parseTag(d)
+		if err != nil {
+			continue
+		}
+		if tag.Context().Name() == source.Context().Name() {
+			diags.AddWarning("image tag not deleted",
+				fmt.Sprintf("%s: the image is still referenced by the source %s", d, source))
+			continue
+		}
+		tags, err := getRepositoryTags(ctx, registryOpts, tag.Context().Name(), digest)
+		if err != nil {
+			diags.AddWarning("image tag not deleted",
+				fmt.Sprintf("%s: failed to list the other tags of the image: %v", d, err))
+			continue
+		}
+		var referenced []string
+		for _, t := range tags {
+			if _, ok := removed[t]; !ok {
+				referenced = append(referenced, t)
+			}
+		}
+		if len(referenced) != 0 {
+			diags.AddWarning("image tag not deleted",
+				fmt.Sprintf("%s: the image is still referenced by %s", d, strings.Join(referenced, ", ")))
+			continue
+		}
+
+		if err = deleteTag(ctx, registryOpts, d); err != nil {
+			diags.AddWarning("failed to delete image tag", fmt.Sprintf("%s: %v", d, err))
+		}
+	}
+	return diags
+}
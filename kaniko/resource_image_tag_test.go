@@ -0,0 +1,150 @@
+package kaniko
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestImageTagUntag(t *testing.T) {
+	testCases := []struct {
+		name         string
+		source       string
+		tags         []string
+		destinations []string
+		removed      []string
+		deleted      []string
+	}{
+		{
+			name:         "tags of another repository are deleted",
+			source:       "source/app:1",
+			destinations: []string{"prod/app:1", "prod/app:latest"},
+			removed:      []string{"prod/app:1", "prod/app:latest"},
+			deleted:      []string{"prod/app:1", "prod/app:latest"},
+		},
+		{
+			name:         "tags of the source repository are kept",
+			source:       "staging/app:1",
+			destinations: []string{"staging/app:prod"},
+			removed:      []string{"staging/app:prod"},
+		},
+		{
+			name:         "tags sharing the image with other tags are kept",
+			source:       "source/app:1",
+			tags:         []string{"prod/app:manual"},
+			destinations: []string{"prod/app:1"},
+			removed:      []string{"prod/app:1"},
+		},
+		{
+			name:         "tags sharing the image with the kept destinations are kept",
+			source:       "source/app:1",
+			destinations: []string{"prod/app:1", "prod/app:latest"},
+			removed:      []string{"prod/app:latest"},
+		},
+		{
+			name:         "tags of other images are kept",
+			source:       "source/app:1",
+			destinations: []string{"prod/app:1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			defer s.Close()
+			u, err := url.Parse(s.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &imageTagResource{providerData: &providerData{InsecureRegistries: []string{u.Host}}}
+			opts := r.providerData.registryOptions()
+			ref := func(s string) string {
+				return u.Host + "/" + s
+			}
+			push := func(s string) string {
+				img, err := random.Image(64, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tag, err := name.NewTag(ref(s), name.Insecure)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = remote.Write(tag, img, opts.remoteOptions(ctx)...); err != nil {
+					t.Fatal(err)
+				}
+				digest, err := img.Digest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return digest.String()
+			}
+
+			push(tc.source)
+			var destinations []attr.Value
+			for _, d := range tc.destinations {
+				destinations = append(destinations, types.StringValue(ref(d)))
+			}
+			state, err := r.copy(ctx, imageTagResourceModel{
+				Source:           types.StringValue(ref(tc.source)),
+				Destinations:     types.ListValueMust(types.StringType, destinations),
+				RegistryUsername: types.StringNull(),
+				RegistryPassword: types.StringNull(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tag := range tc.tags {
+				desc, err := getDescriptor(ctx, opts, ref(tc.source))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = copyImage(ctx, opts, desc, ref(tag)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(tc.removed) == 0 {
+				// Move the destinations to another image.
+				for _, d := range tc.destinations {
+					push(d)
+				}
+				tc.removed = tc.destinations
+			}
+
+			removed := make([]string, 0, len(tc.removed))
+			for _, d := range tc.removed {
+				removed = append(removed, ref(d))
+			}
+			diags := r.untag(ctx, *state, removed)
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			deleted := make(map[string]bool, len(tc.deleted))
+			for _, d := range tc.deleted {
+				deleted[d] = true
+			}
+			for _, d := range tc.destinations {
+				digest, err := getTagDigest(ctx, opts, ref(d))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := digest == ""; got != deleted[d] {
+					t.Errorf("expected %s deleted %t, got %t", d, deleted[d], got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package kaniko
+
+import (
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// mergeStringMap returns a copy of base overridden by the elements of m,
+// null or unknown elements are ignored.
+func mergeStringMap(base map[string]string, m types.Map) map[string]string {
+	out := make(map[string]string, len(base)+len(m.Elements()))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range m.Elements() {
+		s, ok := v.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+		out[k] = s.ValueString()
+	}
+	return out
+}
+
+// stringMapValue returns the map value of the given string map.
+func stringMapValue(m map[string]string) types.Map {
+	elements := make(map[string]attr.Value, len(m))
+	for k, v := range m {
+		elements[k] = types.StringValue(v)
+	}
+	return types.MapValueMust(types.StringType, elements)
+}
+
+// stringList returns the known string elements of the given list.
+func stringList(l types.List) []string {
+	out := make([]string, 0, len(l.Elements()))
+	for _, v := range l.Elements() {
+		s, ok := v.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+		out = append(out, s.ValueString())
+	}
+	return out
+}
+
+// mergeRegistryCertificates returns a copy of base overridden by the known certificates of the given blocks by host,
+// the blocks are unknown while generated by a dynamic block of unknown values.
+func mergeRegistryCertificates(base map[string]string, certs types.List) map[string]string {
+	out := make(map[string]string, len(base)+len(certs.Elements()))
+	for k, v := range base {
+		out[k] = v
+	}
+	for _, v := range certs.Elements() {
+		o, ok := v.(types.Object)
+		if !ok || o.IsNull() || o.IsUnknown() {
+			continue
+		}
+		host, _ := o.Attributes()["host"].(types.String)
+		pem, _ := o.Attributes()["ca_pem"].(types.String)
+		if host.IsNull() || host.IsUnknown() || pem.IsNull() || pem.IsUnknown() {
+			continue
+		}
+		out[host.ValueString()] = pem.ValueString()
+	}
+	return out
+}
+
+// mergeRegistryAuths returns a copy of base overridden by the known credentials of the given blocks
+// by the host of their address.
+func mergeRegistryAuths(base map[string]authn.AuthConfig, auths types.List) map[string]authn.AuthConfig {
+	out := make(map[string]authn.AuthConfig, len(base)+len(auths.Elements()))
+	for k, v := range base {
+		out[k] = v
+	}
+	for _, v := range auths.Elements() {
+		o, ok := v.(types.Object)
+		if !ok || o.IsNull() || o.IsUnknown() {
+			continue
+		}
+		address, _ := o.Attributes()["address"].(types.String)
+		username, _ := o.Attributes()["username"].(types.String)
+		password, _ := o.Attributes()["password"].(types.String)
+		if address.IsNull() || address.IsUnknown() || username.IsUnknown() || password.IsUnknown() {
+			continue
+		}
+		host, err := registryHost(address.ValueString())
+		if err != nil {
+			continue
+		}
+		out[host] = authn.AuthConfig{Username: username.ValueString(), Password: password.ValueString()}
+	}
+	return out
+}
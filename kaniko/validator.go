@@ -0,0 +1,363 @@
+package kaniko
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// verbosityLevels are the log levels accepted by kaniko.
+var verbosityLevels = []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
+
+// contextSchemes are the build context schemes accepted by kaniko,
+// a context without scheme is a local directory.
+var contextSchemes = []string{"dir", "tar", "git", "gs", "s3", "https"}
+
+// cacheClaimAccessModes are the access modes to create the cache claim with.
+var cacheClaimAccessModes = []string{"ReadWriteOnce", "ReadOnlyMany", "ReadWriteMany", "ReadWriteOncePod"}
+
+// OneOfValidator returns a validator checks the string is one of the given values.
+func OneOfValidator(values ...string) validator.String {
+	return oneOfValidator{values: values}
+}
+
+// oneOfValidator implements the validator.
+type oneOfValidator struct {
+	values []string
+}
+
+// Description returns a human-readable description of the validator.
+func (v oneOfValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("Value must be one of: %s.", strings.Join(v.values, ", "))
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v oneOfValidator) MarkdownDescription(_ context.Context) string {
+	return fmt.Sprintf("Value must be one of: `%s`.", strings.Join(v.values, "`, `"))
+}
+
+// ValidateString implements the validation logic.
+func (v oneOfValidator) ValidateString(
+	ctx context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	for _, s := range v.values {
+		if value == s {
+			return
+		}
+	}
+
+	resp.Diagnostics.AddAttributeError(req.Path, "invalid value",
+		fmt.Sprintf("%s got: %q.", v.Description(ctx), value))
+}
+
+// AtLeastValidator returns a validator checks the integer is at least the given minimum.
+func AtLeastValidator(minimum int64) validator.Int64 {
+	return atLeastValidator{minimum: minimum}
+}
+
+// atLeastValidator implements the validator.
+type atLeastValidator struct {
+	minimum int64
+}
+
+// Description returns a human-readable description of the validator.
+func (v atLeastValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("Value must be at least %d.", v.minimum)
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v atLeastValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+// ValidateInt64 implements the validation logic.
+func (v atLeastValidator) ValidateInt64(
+	ctx context.Context, req validator.Int64Request, resp *validator.Int64Response,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if value := req.ConfigValue.ValueInt64(); value < v.minimum {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid value",
+			fmt.Sprintf("%s got: %d.", v.Description(ctx), value))
+	}
+}
+
+// ImageReferenceValidator returns a validator checks the string is a valid image reference.
+func ImageReferenceValidator() validator.String {
+	return imageReferenceValidator{}
+}
+
+// imageReferenceValidator implements the validator.
+type imageReferenceValidator struct{}
+
+// Description returns a human-readable description of the validator.
+func (v imageReferenceValidator) Description(_ context.Context) string {
+	return "Value must be a valid image reference, e.g. registry/repository:tag."
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v imageReferenceValidator) MarkdownDescription(_ context.Context) string {
+	return "Value must be a valid image reference, e.g. `registry/repository:tag`."
+}
+
+// ValidateString implements the validation logic.
+func (v imageReferenceValidator) ValidateString(
+	_ context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := name.ParseReference(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid image reference", err.Error())
+	}
+}
+
+// ImageTagValidator returns a validator checks the string is a valid image tag reference.
+func ImageTagValidator() validator.String {
+	return imageTagValidator{}
+}
+
+// imageTagValidator implements the validator.
+type imageTagValidator struct{}
+
+// Description returns a human-readable description of the validator.
+func (v imageTagValidator) Description(_ context.Context) string {
+	return "Value must be a valid image tag reference, e.g. registry/repository:tag."
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v imageTagValidator) MarkdownDescription(_ context.Context) string {
+	return "Value must be a valid image tag reference, e.g. `registry/repository:tag`."
+}
+
+// ValidateString implements the validation logic.
+func (v imageTagValidator) ValidateString(
+	_ context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if strings.Contains(req.ConfigValue.ValueString(), "@") {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid image tag",
+			fmt.Sprintf("%q is a digest reference, not a tag.", req.ConfigValue.ValueString()))
+		return
+	}
+	if _, err := name.NewTag(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid image tag", err.Error())
+	}
+}
+
+// RegistryAddressValidator returns a validator checks the string is a valid registry address.
+func RegistryAddressValidator() validator.String {
+	return registryAddressValidator{}
+}
+
+// registryAddressValidator implements the validator.
+type registryAddressValidator struct{}
+
+// Description returns a human-readable description of the validator.
+func (v registryAddressValidator) Description(_ context.Context) string {
+	return "Value must be a valid registry address, e.g. harbor.local or https://index.docker.io/v1/."
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v registryAddressValidator) MarkdownDescription(_ context.Context) string {
+	return "Value must be a valid registry address, e.g. `harbor.local` or `https://index.docker.io/v1/`."
+}
+
+// ValidateString implements the validation logic.
+func (v registryAddressValidator) ValidateString(
+	_ context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if req.ConfigValue.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid registry address", "the address must not be empty.")
+		return
+	}
+	if _, err := registryHost(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid registry address", err.Error())
+	}
+}
+
+// BuildContextValidator returns a validator checks the string is a build context supported by kaniko.
+func BuildContextValidator() validator.String {
+	return buildContextValidator{}
+}
+
+// buildContextValidator implements the validator.
+type buildContextValidator struct{}
+
+// Description returns a human-readable description of the validator.
+func (v buildContextValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("Value must be a local path or use one of the schemes: %s.",
+		strings.Join(contextSchemes, ", "))
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v buildContextValidator) MarkdownDescription(_ context.Context) string {
+	return fmt.Sprintf("Value must be a local path or use one of the schemes: `%s`.",
+		strings.Join(contextSchemes, "`, `"))
+}
+
+// ValidateString implements the validation logic.
+func (v buildContextValidator) ValidateString(
+	ctx context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if value == "" {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid build context", "Value must not be empty.")
+		return
+	}
+
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return
+	}
+	for _, s := range contextSchemes {
+		if scheme == s {
+			return
+		}
+	}
+
+	resp.Diagnostics.AddAttributeError(req.Path, "invalid build context",
+		fmt.Sprintf("%s got: %q.", v.Description(ctx), scheme))
+}
+
+// BuildArgKeysValidator returns a validator checks the keys of a build arguments map.
+func BuildArgKeysValidator() validator.Map {
+	return buildArgKeysValidator{}
+}
+
+// buildArgKeysValidator implements the validator.
+type buildArgKeysValidator struct{}
+
+// Description returns a human-readable description of the validator.
+func (v buildArgKeysValidator) Description(_ context.Context) string {
+	return "Keys must not be empty or contain \"=\"."
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v buildArgKeysValidator) MarkdownDescription(_ context.Context) string {
+	return "Keys must not be empty or contain `=`."
+}
+
+// ValidateMap implements the validation logic.
+func (v buildArgKeysValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for k := range req.ConfigValue.Elements() {
+		if k == "" || strings.Contains(k, "=") {
+			resp.Diagnostics.AddAttributeError(req.Path, "invalid build argument",
+				fmt.Sprintf("%s got: %q.", v.Description(ctx), k))
+		}
+	}
+}
+
+// PlatformValidator returns a validator checks the string is a platform, e.g. linux/arm64/v8.
+func PlatformValidator() validator.String {
+	return platformValidator{}
+}
+
+// platformValidator implements the validator.
+type platformValidator struct{}
+
+// Description returns a human-readable description of the validator.
+func (v platformValidator) Description(_ context.Context) string {
+	return "Value must be a platform in the form os/arch[/variant], e.g. linux/arm64."
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v platformValidator) MarkdownDescription(_ context.Context) string {
+	return "Value must be a platform in the form `os/arch[/variant]`, e.g. `linux/arm64`."
+}
+
+// ValidateString implements the validation logic.
+func (v platformValidator) ValidateString(
+	_ context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := parsePlatform(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid platform", err.Error())
+	}
+}
+
+// ValueStringsAreValidator returns a validator checks each element of a list of strings
+// with the given validators.
+func ValueStringsAreValidator(validators ...validator.String) validator.List {
+	return valueStringsAreValidator{validators: validators}
+}
+
+// valueStringsAreValidator implements the validator.
+type valueStringsAreValidator struct {
+	validators []validator.String
+}
+
+// Description returns a human-readable description of the validator.
+func (v valueStringsAreValidator) Description(ctx context.Context) string {
+	descriptions := make([]string, 0, len(v.validators))
+	for _, sv := range v.validators {
+		descriptions = append(descriptions, sv.Description(ctx))
+	}
+	return "Each element: " + strings.Join(descriptions, " ")
+}
+
+// MarkdownDescription returns a markdown description of the validator.
+func (v valueStringsAreValidator) MarkdownDescription(ctx context.Context) string {
+	descriptions := make([]string, 0, len(v.validators))
+	for _, sv := range v.validators {
+		descriptions = append(descriptions, sv.MarkdownDescription(ctx))
+	}
+	return "Each element: " + strings.Join(descriptions, " ")
+}
+
+// ValidateList implements the validation logic.
+func (v valueStringsAreValidator) ValidateList(
+	ctx context.Context, req validator.ListRequest, resp *validator.ListResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for i, e := range req.ConfigValue.Elements() {
+		s, ok := e.(types.String)
+		if !ok {
+			continue
+		}
+		elementReq := validator.StringRequest{
+			Path:           req.Path.AtListIndex(i),
+			PathExpression: req.PathExpression.AtListIndex(i),
+			Config:         req.Config,
+			ConfigValue:    s,
+		}
+		for _, sv := range v.validators {
+			elementResp := &validator.StringResponse{}
+			sv.ValidateString(ctx, elementReq, elementResp)
+			resp.Diagnostics.Append(elementResp.Diagnostics...)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package kaniko
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	apibatchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	warmerImage = "gcr.io/kaniko-project/warmer:v1.23.2"
+	// cacheDir is where the base image cache volume is mounted.
+	cacheDir             = "/cache"
+	cacheVolumeName      = "cache"
+	cacheClaimName       = "kaniko-cache"
+	warmerContainerName  = "warm"
+	warmerIDPrefix       = "kaniko-warmer-"
+	warmerIDHashedLength = 16
+)
+
+type warmOptions struct {
+	ID string
+
+	Images    []string
+	ClaimName string
+	// Size, storage class and access mode to create the claim with if it does not exist.
+	ClaimSize         string
+	ClaimStorageClass string
+	ClaimAccessMode   string
+	Verbosity         string
+	Proxy             proxyOptions
+
+	// Credentials of the registries of the images by host.
+	RegistryAuths map[string]authn.AuthConfig
+
+	InsecureRegistries   []string
+	SkipTLSVerifyPull    bool
+	RegistryCertificates map[string]string
+	RegistryMirrors      []string
+	RegistryMap          map[string]string
+
+	KeepFailedBuilds             bool
+	ServiceAccountName           string
+	AutomountServiceAccountToken *bool
+	PodAnnotations               map[string]string
+	PodLabels                    map[string]string
+	Annotations                  map[string]string
+	Labels                       map[string]string
+}
+
+// warmerID returns the id of the warmer derived from its inputs.
+func (o *warmOptions) warmerID() string {
+	b, _ := json.Marshal([]any{warmerImage, o.Images, o.ClaimName})
+	sum := sha256.Sum256(b)
+	return warmerIDPrefix + hex.EncodeToString(sum[:])[:warmerIDHashedLength]
+}
+
+// hasCredentials returns whether the registry credentials are set.
+func (o *warmOptions) hasCredentials() bool {
+	return len(o.RegistryAuths) != 0
+}
+
+// getImageRegistryAuths returns the credentials of the registries of the given images by host,
+// the credentials of the other registries are left out.
+func getImageRegistryAuths(images []string, auths map[string]authn.AuthConfig) (map[string]authn.AuthConfig, error) {
+	out := make(map[string]authn.AuthConfig)
+	for _, image := range images {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return nil, err
+		}
+		if auth, ok := auths[ref.Context().RegistryStr()]; ok {
+			out[ref.Context().RegistryStr()] = auth
+		}
+	}
+	return out, nil
+}
+
+// kanikoWarm runs the kaniko warmer to populate the cache volume with the base images.
+func kanikoWarm(ctx context.Context, restConfig *rest.Config, opts *warmOptions) (*runResult, error) {
+	coreV1Client, err := v1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	batchV1Client, err := batchv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := getNamespace()
+
+	if opts.ClaimSize != "" {
+		err = ensureCacheClaim(ctx, coreV1Client, namespace, cacheClaimOptions{
+			Name:         opts.ClaimName,
+			Size:         opts.ClaimSize,
+			StorageClass: opts.ClaimStorageClass,
+			AccessMode:   opts.ClaimAccessMode,
+			Annotations:  opts.Annotations,
+			Labels:       opts.Labels,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	secret, err := getWarmerSecret(namespace, opts)
+	if err != nil {
+		return nil, err
+	}
+	job := getWarmerJob(namespace, opts)
+	if err = submitJob(ctx, coreV1Client, batchV1Client, secret, job); err != nil {
+		return nil, err
+	}
+
+	var failed bool
+	defer func() {
+		cleanupJob(ctx, coreV1Client, batchV1Client, namespace, opts.ID, failed && opts.KeepFailedBuilds)
+	}()
+
+	succeeded, err := waitForJob(ctx, coreV1Client, batchV1Client, namespace, opts.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !succeeded {
+		failed = true
+		return nil, getJobFailure(ctx, restConfig, namespace, opts.ID, opts.KeepFailedBuilds)
+	}
+
+	return &runResult{ID: opts.ID}, nil
+}
+
+// getWarmerLabels returns the labels of the kubernetes objects created for a warmer.
+func getWarmerLabels(opts *warmOptions, extra ...map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for _, m := range append([]map[string]string{opts.Labels}, extra...) {
+		for k, v := range m {
+			labels[k] = v
+		}
+	}
+
+	labels[labelManagedBy] = managedBy
+	labels[labelBuildID] = opts.ID
+	return labels
+}
+
+// getWarmerSecret returns the secret of the registry credentials and certificates of a warmer,
+// or nil if there are none.
+func getWarmerSecret(namespace string, opts *warmOptions) (*apiv1.Secret, error) {
+	var cfg *DockerConfigJSON
+	if opts.hasCredentials() {
+		cfg = &DockerConfigJSON{Auths: make(map[string]authn.AuthConfig)}
+		for host, auth := range opts.RegistryAuths {
+			cfg.Auths[registryAuthKey(host)] = auth
+		}
+	} else if len(opts.RegistryCertificates) == 0 {
+		return nil, nil
+	}
+
+	data, err := getRegistrySecretData(cfg, opts.RegistryCertificates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        opts.ID,
+			Labels:      getWarmerLabels(opts),
+			Annotations: opts.Annotations,
+		},
+		Data: data,
+	}, nil
+}
+
+func getWarmerJob(namespace string, opts *warmOptions) *apibatchv1.Job {
+	args := []string{
+		fmt.Sprintf("--cache-dir=%s", cacheDir),
+		fmt.Sprintf("--verbosity=%s", opts.Verbosity),
+	}
+	for _, image := range opts.Images {
+		args = append(args, fmt.Sprintf("--image=%s", image))
+	}
+	args = append(args, getRegistryMirrorArgs(opts.RegistryMirrors, opts.RegistryMap)...)
+	for _, r := range opts.InsecureRegistries {
+		args = append(args, fmt.Sprintf("--insecure-registry=%s", r))
+	}
+	if opts.SkipTLSVerifyPull {
+		args = append(args, "--skip-tls-verify-pull")
+	}
+	certificateArgs, certificateItems := getRegistryCertificateArgs(opts.RegistryCertificates)
+	args = append(args, certificateArgs...)
+
+	volumeMounts, volumes := getRegistrySecretVolumes(opts.ID, opts.hasCredentials(), certificateItems)
+	volumeMounts = append(volumeMounts, apiv1.VolumeMount{
+		Name:      cacheVolumeName,
+		MountPath: cacheDir,
+	})
+	volumes = append(volumes, apiv1.Volume{
+		Name: cacheVolumeName,
+		VolumeSource: apiv1.VolumeSource{
+			PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{
+				ClaimName: opts.ClaimName,
+			},
+		},
+	})
+
+	annotations := make(map[string]string)
+	podAnnotations := make(map[string]string)
+	for k, v := range opts.Annotations {
+		annotations[k] = v
+		podAnnotations[k] = v
+	}
+	for k, v := range opts.PodAnnotations {
+		podAnnotations[k] = v
+	}
+
+	return &apibatchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        opts.ID,
+			Labels:      getWarmerLabels(opts),
+			Annotations: annotations,
+		},
+		Spec: apibatchv1.JobSpec{
+			BackoffLimit:            pointer.Int32(0),
+			TTLSecondsAfterFinished: pointer.Int32(3600),
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: podAnnotations,
+					Labels:      getWarmerLabels(opts, opts.PodLabels),
+				},
+				Spec: apiv1.PodSpec{
+					ServiceAccountName:           opts.ServiceAccountName,
+					AutomountServiceAccountToken: opts.AutomountServiceAccountToken,
+					Containers: []apiv1.Container{
+						{
+							Name:         warmerContainerName,
+							Image:        warmerImage,
+							Args:         args,
+							Env:          opts.Proxy.env(),
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes:       volumes,
+					RestartPolicy: apiv1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+}
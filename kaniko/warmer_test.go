@@ -0,0 +1,69 @@
+package kaniko
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+func TestGetWarmerSecret(t *testing.T) {
+	auths := map[string]authn.AuthConfig{
+		"harbor.local":    {Username: "harbor", Password: "secret"},
+		"index.docker.io": {Username: "hub", Password: "token"},
+		"ghcr.io":         {Username: "github", Password: "token"},
+	}
+
+	testCases := []struct {
+		name     string
+		images   []string
+		expected map[string]authn.AuthConfig
+	}{
+		{
+			name:   "no credentials of the registries of the images",
+			images: []string{"gcr.io/distroless/static:nonroot"},
+		},
+		{
+			name:   "credentials of the registries of the images only",
+			images: []string{"harbor.local/library/golang:1.19", "alpine:3.17", "gcr.io/distroless/static:nonroot"},
+			expected: map[string]authn.AuthConfig{
+				"https://harbor.local/v1/":    {Username: "harbor", Password: "secret"},
+				"https://index.docker.io/v1/": {Username: "hub", Password: "token"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registryAuths, err := getImageRegistryAuths(tc.images, auths)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			opts := &warmOptions{ID: "kaniko-warmer-0123456789abcdef", Images: tc.images, RegistryAuths: registryAuths}
+			secret, err := getWarmerSecret("default", opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expected == nil {
+				if secret != nil {
+					t.Errorf("expected no secret, got %v", secret.Data)
+				}
+				return
+			}
+
+			var cfg DockerConfigJSON
+			if err = json.Unmarshal(secret.Data[dockerConfigKey], &cfg); err != nil {
+				t.Fatal(err)
+			}
+			// The encoded auth is decoded into the credentials.
+			got := make(map[string]authn.AuthConfig, len(cfg.Auths))
+			for k, v := range cfg.Auths {
+				got[k] = authn.AuthConfig{Username: v.Username, Password: v.Password}
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// Semver is a parsed semantic version, the build metadata is ignored.
+type Semver struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+}
+
+// ParseSemver parses the given string as a semantic version with an optional "v" prefix,
+// it returns false if the string is not a semantic version.
+func ParseSemver(s string) (Semver, bool) {
+	m := semverRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Semver{}, false
+	}
+
+	var (
+		v   Semver
+		err error
+	)
+	if v.Major, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+		return Semver{}, false
+	}
+	if v.Minor, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+		return Semver{}, false
+	}
+	if v.Patch, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+		return Semver{}, false
+	}
+	if m[4] != "" {
+		v.Prerelease = strings.Split(m[4], ".")
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 if the version has lower, equal or higher precedence than the other one.
+func (v Semver) Compare(o Semver) int {
+	for _, c := range [][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] != c[1] {
+			return compare(c[0] > c[1])
+		}
+	}
+
+	// A release has higher precedence than its pre-releases.
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		a, b := v.Prerelease[i], o.Prerelease[i]
+		if a == b {
+			continue
+		}
+
+		an, aErr := strconv.ParseUint(a, 10, 64)
+		bn, bErr := strconv.ParseUint(b, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			return compare(an > bn)
+		case aErr == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones.
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			return compare(a > b)
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) == len(o.Prerelease):
+		return 0
+	default:
+		return compare(len(v.Prerelease) > len(o.Prerelease))
+	}
+}
+
+func compare(greater bool) int {
+	if greater {
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	testCases := []struct {
+		name     string
+		version  string
+		expected Semver
+		ok       bool
+	}{
+		{
+			name:     "release",
+			version:  "1.2.3",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3},
+			ok:       true,
+		},
+		{
+			name:     "v prefix",
+			version:  "v1.2.3",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3},
+			ok:       true,
+		},
+		{
+			name:     "pre-release",
+			version:  "1.2.3-rc.1",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc", "1"}},
+			ok:       true,
+		},
+		{
+			name:     "build metadata is ignored",
+			version:  "1.2.3+build.5",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3},
+			ok:       true,
+		},
+		{
+			name:     "pre-release and build metadata",
+			version:  "v1.2.3-beta+exp.sha.5114f85",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"beta"}},
+			ok:       true,
+		},
+		{
+			name:    "latest",
+			version: "latest",
+		},
+		{
+			name:    "missing patch",
+			version: "1.2",
+		},
+		{
+			name:    "leading zero",
+			version: "01.2.3",
+		},
+		{
+			name:    "upper case prefix",
+			version: "V1.2.3",
+		},
+		{
+			name:    "suffix",
+			version: "1.2.3-alpine_3",
+		},
+		{
+			name:    "empty pre-release identifier",
+			version: "1.2.3-rc..1",
+		},
+		{
+			name:    "overflow",
+			version: "18446744073709551616.0.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, ok := ParseSemver(tc.version)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %t, got %t", tc.ok, ok)
+			}
+			if !reflect.DeepEqual(v, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected int
+	}{
+		{name: "equal", a: "1.2.3", b: "1.2.3", expected: 0},
+		{name: "v prefix is ignored", a: "v1.2.3", b: "1.2.3", expected: 0},
+		{name: "build metadata is ignored", a: "1.2.3+1", b: "1.2.3+2", expected: 0},
+		{name: "major", a: "2.0.0", b: "1.9.9", expected: 1},
+		{name: "minor", a: "1.2.0", b: "1.10.0", expected: -1},
+		{name: "patch", a: "1.2.10", b: "1.2.9", expected: 1},
+		{name: "release over pre-release", a: "1.0.0", b: "1.0.0-rc.1", expected: 1},
+		{name: "pre-release under release", a: "1.0.0-rc.1", b: "1.0.0", expected: -1},
+		{name: "numeric identifiers", a: "1.0.0-rc.2", b: "1.0.0-rc.10", expected: -1},
+		{name: "alphanumeric identifiers", a: "1.0.0-beta", b: "1.0.0-alpha", expected: 1},
+		{name: "numeric under alphanumeric", a: "1.0.0-1", b: "1.0.0-alpha", expected: -1},
+		{name: "alphanumeric over numeric", a: "1.0.0-alpha", b: "1.0.0-1", expected: 1},
+		{name: "more identifiers", a: "1.0.0-alpha.1", b: "1.0.0-alpha", expected: 1},
+		{name: "equal pre-releases", a: "1.0.0-alpha.1", b: "1.0.0-alpha.1", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, ok := ParseSemver(tc.a)
+			if !ok {
+				t.Fatalf("invalid version %q", tc.a)
+			}
+			b, ok := ParseSemver(tc.b)
+			if !ok {
+				t.Fatalf("invalid version %q", tc.b)
+			}
+			if c := a.Compare(b); c != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, c)
+			}
+		})
+	}
+}